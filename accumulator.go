@@ -1,4 +1,36 @@
 package telegraf
 
+import (
+	"time"
+)
+
+// Accumulator is used by an Input to add metrics and report errors while
+// gathering.
+//
+// Existing: accumulator.go Accumulator
 type Accumulator interface {
+	// AddFields adds a metric with the given measurement name, fields and
+	// tags.  The time defaults to now.
+	AddFields(measurement string,
+		fields map[string]interface{},
+		tags map[string]string,
+		t ...time.Time)
+
+	// AddGauge is the same as AddFields, but the metric is a Gauge.
+	AddGauge(measurement string,
+		fields map[string]interface{},
+		tags map[string]string,
+		t ...time.Time)
+
+	// AddCounter is the same as AddFields, but the metric is a Counter.
+	AddCounter(measurement string,
+		fields map[string]interface{},
+		tags map[string]string,
+		t ...time.Time)
+
+	// AddMetric adds a metric created by the Input, such as by a Parser.
+	AddMetric(Metric)
+
+	// AddError reports an error that occurred while gathering.
+	AddError(err error)
 }
@@ -8,23 +8,29 @@ import (
 	"os"
 	"os/signal"
 	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	telegraf "github.com/influxdata/tgconfig"
 	"github.com/influxdata/tgconfig/models"
+	"github.com/influxdata/tgconfig/plugins/aggregators"
 	"github.com/influxdata/tgconfig/plugins/inputs"
 	"github.com/influxdata/tgconfig/plugins/loaders"
 	"github.com/influxdata/tgconfig/plugins/loaders/toml"
 	"github.com/influxdata/tgconfig/plugins/outputs"
 	"github.com/influxdata/tgconfig/plugins/parsers"
+	"github.com/influxdata/tgconfig/plugins/processors"
+	"github.com/influxdata/tgconfig/plugins/serializers"
 )
 
 // Agent represents the main event loop
 type Agent struct {
-	flags      *Flags
-	registry   telegraf.Registry
-	mainLoader *models.RunningLoader
+	flags         *Flags
+	registry      telegraf.Registry
+	defaultLoader *models.RunningLoader
+	mainLoader    *models.RunningLoader
 }
 
 // Flags are the initialization options that cannot be changed
@@ -34,48 +40,70 @@ type Flags struct {
 	Debug      bool
 	RunTimeout time.Duration
 	Args       []string
+
+	// MaxLoaderDepth limits how deeply Loaders may be nested, the Loaders
+	// declared in the main config are at depth 1.
+	MaxLoaderDepth int
 }
 
+// DefaultMaxLoaderDepth is used when Flags.MaxLoaderDepth is not set.
+const DefaultMaxLoaderDepth = 4
+
 func NewAgent(flags *Flags) (*Agent, error) {
 	registry, err := models.NewRegistry(
 		loaders.Loaders,
 		inputs.Inputs,
 		outputs.Outputs,
 		parsers.Parsers,
+		processors.Processors,
+		aggregators.Aggregators,
+		serializers.Serializers,
+		loaders.Formats,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	// Load the base configuration; required and always using the toml config
+	// The built-in defaults are always loaded and form the base layer that
+	// the user configuration is overlaid on.
+	defaultLoader := newDefaultsLoader()
+
+	// Load the base configuration; optional and always using the toml config
 	// plugin.  This file might contain as little as another config plugin.
 	// Global tags need to be passed along.
-	var configfile string
+	var mainLoader *models.RunningLoader
 	if len(flags.Args) > 0 {
-		configfile = flags.Args[0]
+		mainLoader, err = createLoader(toml.Name, &toml.Config{Path: flags.Args[0]}, registry)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	mainLoader, err := createMainLoader(configfile, registry)
-	if err != nil {
-		return nil, err
+	if flags.MaxLoaderDepth <= 0 {
+		flags.MaxLoaderDepth = DefaultMaxLoaderDepth
 	}
 
 	agent := &Agent{
-		flags:      flags,
-		registry:   registry,
-		mainLoader: mainLoader,
+		flags:         flags,
+		registry:      registry,
+		defaultLoader: defaultLoader,
+		mainLoader:    mainLoader,
 	}
 
 	return agent, nil
 }
 
-func createMainLoader(path string, registry telegraf.Registry) (*models.RunningLoader, error) {
+func createLoader(
+	name string,
+	pluginConfig telegraf.PluginConfig,
+	registry telegraf.Registry,
+) (*models.RunningLoader, error) {
 	config := &telegraf.LoaderConfig{
 		Config:       &telegraf.CommonLoaderConfig{},
-		PluginConfig: &toml.Config{Path: path},
+		PluginConfig: pluginConfig,
 	}
 
-	loaders, err := models.NewRunningLoaders("toml", config, registry)
+	loaders, err := models.NewRunningLoaders(name, config, registry)
 	if err != nil {
 		return nil, err
 	}
@@ -93,17 +121,48 @@ func createPlugin(config interface{}, factory interface{}) interface{} {
 }
 
 type Pipeline struct {
-	Acc     telegraf.Accumulator
-	Inputs  []*models.RunningInput
-	Outputs []*models.RunningOutput
-	Loaders []*models.RunningLoader
+	Agent       telegraf.AgentConfig
+	Inputs      []*models.RunningInput
+	Outputs     []*models.RunningOutput
+	Processors  models.ProcessorChain
+	Aggregators []*models.RunningAggregator
+	Loaders     []*models.RunningLoader
+
+	// LoaderTree shows which Loader declared each of the Loaders.
+	LoaderTree []*LoaderNode
+
+	// metrics carries the metrics from the input Accumulators to the outputs.
+	metrics chan telegraf.Metric
+	wg      sync.WaitGroup
+
+	// aggregates carries the metrics pushed by the aggregators to the
+	// outputs.
+	aggregates   chan telegraf.Metric
+	aggregatesWg sync.WaitGroup
+
+	cancelAggregators context.CancelFunc
+	aggregatorsWg     sync.WaitGroup
+
+	cancelInputs context.CancelFunc
+	inputsWg     sync.WaitGroup
+
+	cancelOutputs context.CancelFunc
+	outputsWg     sync.WaitGroup
+}
+
+// LoaderNode is a Loader along with the Loaders declared in its config.
+type LoaderNode struct {
+	Loader   *models.RunningLoader
+	Children []*LoaderNode
 }
 
 func NewPipeline() *Pipeline {
 	p := &Pipeline{}
-	p.Acc = &models.Accumulator{}
+	p.Agent = telegraf.DefaultAgentConfig()
 	p.Inputs = make([]*models.RunningInput, 0)
 	p.Outputs = make([]*models.RunningOutput, 0)
+	p.Processors = make(models.ProcessorChain, 0)
+	p.Aggregators = make([]*models.RunningAggregator, 0)
 	p.Loaders = make([]*models.RunningLoader, 0)
 	return p
 }
@@ -116,25 +175,169 @@ func (p *Pipeline) AddOutputs(outputs ...*models.RunningOutput) {
 	p.Outputs = append(p.Outputs, outputs...)
 }
 
+func (p *Pipeline) AddAggregators(aggregators ...*models.RunningAggregator) {
+	p.Aggregators = append(p.Aggregators, aggregators...)
+}
+
 func (p *Pipeline) AddLoaders(loaders ...*models.RunningLoader) {
 	p.Loaders = append(p.Loaders, loaders...)
 }
 
+// Connect connects all outputs.  If any output fails to connect the
+// outputs already connected are closed.
+func (p *Pipeline) Connect() error {
+	for i, output := range p.Outputs {
+		err := output.Output.Connect()
+		if err != nil {
+			closeOutputs(p.Outputs[:i])
+			return fmt.Errorf("outputs.%s: connect: %v", output.Name, err)
+		}
+	}
+	return nil
+}
+
+// Start starts gathering the inputs and delivering their metrics to the
+// outputs.
+func (p *Pipeline) Start(ctx context.Context) {
+	p.metrics = make(chan telegraf.Metric, 100)
+	p.aggregates = make(chan telegraf.Metric, 100)
+
+	var outputCtx context.Context
+	outputCtx, p.cancelOutputs = context.WithCancel(ctx)
+	for _, output := range p.Outputs {
+		p.outputsWg.Add(1)
+		go func(output *models.RunningOutput) {
+			defer p.outputsWg.Done()
+			flushLoop(outputCtx, p.Agent, output)
+		}(output)
+	}
+
+	p.aggregatesWg.Add(1)
+	go func() {
+		defer p.aggregatesWg.Done()
+		for m := range p.aggregates {
+			p.fanOut(m)
+		}
+	}()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		for m := range p.metrics {
+			for _, m := range p.Processors.Apply(m) {
+				if !p.aggregate(m) {
+					p.fanOut(m)
+				}
+			}
+		}
+	}()
+
+	var aggregatorCtx context.Context
+	aggregatorCtx, p.cancelAggregators = context.WithCancel(ctx)
+	now := time.Now()
+	for _, aggregator := range p.Aggregators {
+		start := now
+		if p.Agent.RoundInterval {
+			start = now.Truncate(aggregator.Config.Period.Duration)
+		}
+		aggregator.UpdateWindow(start)
+
+		p.aggregatorsWg.Add(1)
+		go func(aggregator *models.RunningAggregator) {
+			defer p.aggregatorsWg.Done()
+			aggregateLoop(aggregatorCtx, aggregator, p.newAggregatorAccumulator(aggregator))
+		}(aggregator)
+	}
+
+	ctx, p.cancelInputs = context.WithCancel(ctx)
+	for _, input := range p.Inputs {
+		p.inputsWg.Add(1)
+		go func(input *models.RunningInput) {
+			defer p.inputsWg.Done()
+			gatherLoop(ctx, p.Agent, input, p.NewAccumulator(input))
+		}(input)
+	}
+}
+
+// Stop stops gathering, waiting for any gathers in progress to complete, and
+// then delivers all remaining metrics to the outputs with a final flush
+// before closing them.  The aggregators push the aggregates of their partial
+// period before the outputs are flushed.
+func (p *Pipeline) Stop() {
+	p.cancelInputs()
+	p.inputsWg.Wait()
+
+	close(p.metrics)
+	p.wg.Wait()
+
+	p.cancelAggregators()
+	p.aggregatorsWg.Wait()
+	for _, aggregator := range p.Aggregators {
+		aggregator.Push(p.newAggregatorAccumulator(aggregator))
+	}
+	close(p.aggregates)
+	p.aggregatesWg.Wait()
+
+	p.cancelOutputs()
+	p.outputsWg.Wait()
+	for _, output := range p.Outputs {
+		flush(output)
+	}
+	closeOutputs(p.Outputs)
+}
+
+func closeOutputs(outputs []*models.RunningOutput) {
+	for _, output := range outputs {
+		err := output.Output.Close()
+		if err != nil {
+			fmt.Printf("E! [outputs.%s] error closing: %v\n", output.Name, err)
+		}
+	}
+}
+
+// NewAccumulator creates the Accumulator for an input of the pipeline.
+func (p *Pipeline) NewAccumulator(input *models.RunningInput) telegraf.Accumulator {
+	return models.NewAccumulator(input, p.metrics)
+}
+
+// newAggregatorAccumulator creates the Accumulator for an aggregator of the
+// pipeline, the aggregates bypass the processors and aggregators.
+func (p *Pipeline) newAggregatorAccumulator(aggregator *models.RunningAggregator) telegraf.Accumulator {
+	return models.NewAccumulator(aggregator, p.aggregates)
+}
+
+// aggregate adds a metric to every aggregator, reporting if the metric
+// should be dropped instead of being sent to the outputs.
+func (p *Pipeline) aggregate(m telegraf.Metric) bool {
+	drop := false
+	for _, aggregator := range p.Aggregators {
+		if aggregator.Add(m) {
+			drop = true
+		}
+	}
+	return drop
+}
+
+// fanOut delivers a metric to every output, all outputs but the last
+// receive their own copy.
+func (p *Pipeline) fanOut(m telegraf.Metric) {
+	for i, output := range p.Outputs {
+		if i == len(p.Outputs)-1 {
+			output.AddMetric(m)
+		} else {
+			output.AddMetric(m.Copy())
+		}
+	}
+}
+
 // Run starts the main event loop
 func (a *Agent) Run() error {
-	// dealing with recursion:
-	// - only local toml can contain more config plugins? yes but...
-	// - could do a top level parse and pass remaining to plugins. could only do with top toml
-	// don't provide a way to return config plugins.
-	// a plugin could theoretically chain load or whatever for redirection, but it has to load
-	// all the plugins.
-
 	var wg sync.WaitGroup
 
 	ctx := context.Background()
 	ctx, sigcancel := context.WithCancel(ctx)
 
-	signals := make(chan os.Signal)
+	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, os.Interrupt)
 	wg.Add(1)
 	go func() {
@@ -160,37 +363,70 @@ func (a *Agent) Run() error {
 		defer cancel()
 	}
 
+	var running *Pipeline
 	for {
 		var watcher = NewWatcher()
 		pipeline, err := a.LoadPipeline(ctx, watcher)
+		if err == nil {
+			// The new outputs are connected while the running pipeline is
+			// still delivering, it is only stopped, flushing and closing its
+			// outputs, once the new pipeline is ready to take over.
+			err = pipeline.Connect()
+			if err == nil && running != nil {
+				running.Stop()
+			}
+		}
+		if err != nil && running != nil {
+			// Abandon the reload, the previous pipeline continues to run
+			// until the next change.
+			fmt.Printf("reload failed, keeping previous config: %v\n", err)
+			pipeline = nil
+			err = nil
+		}
 		if err != nil {
 			fmt.Println(err)
 			break
 		}
 
-		for _, input := range pipeline.Inputs {
-			fmt.Printf(FormatPlugin(input))
-		}
-		for _, output := range pipeline.Outputs {
-			fmt.Printf(FormatPlugin(output))
-		}
-		for _, loader := range pipeline.Loaders {
-			fmt.Printf(FormatPlugin(loader))
+		if pipeline != nil {
+			running = pipeline
+
+			for _, input := range pipeline.Inputs {
+				fmt.Print(FormatPlugin(input))
+			}
+			for _, output := range pipeline.Outputs {
+				fmt.Print(FormatPlugin(output))
+			}
+			for _, processor := range pipeline.Processors {
+				fmt.Print(FormatPlugin(processor))
+			}
+			for _, aggregator := range pipeline.Aggregators {
+				fmt.Print(FormatPlugin(aggregator))
+			}
+			for _, loader := range pipeline.Loaders {
+				fmt.Print(FormatPlugin(loader))
+			}
+			fmt.Print(FormatLoaderTree(pipeline.LoaderTree))
+
+			pipeline.Start(ctx)
 		}
 
-		// !! Start Pipeline
 		// Wait for Watch to complete
 		watcher.Wait()
 		fmt.Println("Watch Triggered")
-		// !! Stop Pipeline
 
 		if ctx.Err() == context.Canceled {
 			fmt.Println("cancelled: agent")
+			break
 		}
 		if ctx.Err() == context.DeadlineExceeded {
 			fmt.Println("finished timed run: agent")
+			break
 		}
 	}
+	if running != nil {
+		running.Stop()
+	}
 
 	fmt.Println("Run -- finished")
 	sigcancel()
@@ -203,79 +439,186 @@ func (a *Agent) LoadPipeline(ctx context.Context, watcher *watcher) (*Pipeline,
 
 	configreg := a.registry.GetConfigRegistry()
 
-	// Place a watch on the main loader before loading, ensuring that we don't
-	// miss any updates.
-	watcher.WatchLoader(ctx, a.mainLoader)
+	watcher.WatchLoader(ctx, a.defaultLoader)
 
-	fmt.Printf("Loading: %s\n", a.mainLoader.Name)
-	conf, err := a.mainLoader.Load(ctx, configreg)
+	fmt.Printf("Loading: %s\n", a.defaultLoader.Name)
+	defaultConf, err := a.defaultLoader.Load(ctx, configreg)
 	if err != nil {
 		return nil, err
 	}
-	pipeline.AddLoaders(a.mainLoader)
+	pipeline.AddLoaders(a.defaultLoader)
+	pipeline.LoaderTree = append(pipeline.LoaderTree, &LoaderNode{Loader: a.defaultLoader})
 
-	for name, configs := range conf.Inputs {
-		for _, config := range configs {
-			inputs, err := models.NewRunningInputs(name, config, a.registry)
-			if err != nil {
-				return nil, err
-			}
-			pipeline.AddInputs(inputs...)
+	// Without a config file the agent runs with only the defaults.
+	var layers []*layer
+	if a.mainLoader != nil {
+		node, loaded, err := a.loadTree(ctx, watcher, pipeline, a.mainLoader, nil)
+		if err != nil {
+			return nil, err
 		}
+		pipeline.LoaderTree = append(pipeline.LoaderTree, node)
+		layers = loaded
 	}
 
-	for name, configs := range conf.Outputs {
-		for _, config := range configs {
-			outputs, err := models.NewRunningOutputs(name, config, a.registry)
-			if err != nil {
-				return nil, err
+	merged, err := merge(layers)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := overlay(defaultConf, merged)
+	if err := checkAgentConfig(conf.Agent); err != nil {
+		return nil, err
+	}
+	pipeline.Agent = *conf.Agent
+	err = a.addPlugins(pipeline, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return pipeline, nil
+}
+
+// loadTree loads a Loader and then recursively the Loaders declared by its
+// config, returning the resulting tree and the config of each Loader in load
+// order.  The ancestors of the Loader are used to detect cycles and to limit
+// the depth of the tree.
+func (a *Agent) loadTree(
+	ctx context.Context,
+	watcher *watcher,
+	pipeline *Pipeline,
+	loader *models.RunningLoader,
+	ancestors []*models.RunningLoader,
+) (*LoaderNode, []*layer, error) {
+	for i, ancestor := range ancestors {
+		if ancestor.ID == loader.ID {
+			var ids []string
+			for _, l := range ancestors[i:] {
+				ids = append(ids, l.ID)
 			}
-			pipeline.AddOutputs(outputs...)
+			ids = append(ids, loader.ID)
+			return nil, nil, fmt.Errorf("loader cycle detected: %s",
+				strings.Join(ids, " -> "))
 		}
 	}
+	if len(ancestors) > a.flags.MaxLoaderDepth {
+		return nil, nil, fmt.Errorf("loader %s exceeds the maximum depth of %d",
+			loader.ID, a.flags.MaxLoaderDepth)
+	}
+
+	// Place a watch on the loader before loading, ensuring that we don't
+	// miss any updates.
+	watcher.WatchLoader(ctx, loader)
+
+	fmt.Printf("Loading: %s\n", loader.Name)
+	conf, err := loader.Load(ctx, a.registry.GetConfigRegistry())
+	if err != nil {
+		return nil, nil, err
+	}
+	pipeline.AddLoaders(loader)
 
-	for name, configs := range conf.Loaders {
-		var conf *telegraf.Config
-		for _, config := range configs {
+	// A Loader cannot be used to escape the policy of the Loader that
+	// declared it.
+	for _, ancestor := range ancestors {
+		err := ancestor.CheckPolicy(conf)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	node := &LoaderNode{Loader: loader}
+	layers := []*layer{{loader: loader, config: conf, ancestors: ancestors}}
+
+	path := make([]*models.RunningLoader, len(ancestors), len(ancestors)+1)
+	copy(path, ancestors)
+	path = append(path, loader)
+
+	for _, name := range sortedKeys(conf.Loaders) {
+		for _, config := range conf.Loaders[name] {
 			loaders, err := models.NewRunningLoaders(name, config, a.registry)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
-			pipeline.AddLoaders(loaders...)
-
-			for _, loader := range loaders {
-				watcher.WatchLoader(ctx, loader)
 
-				fmt.Printf("Loading: %s\n", name)
-				conf, err = loader.Load(ctx, configreg)
+			for _, child := range loaders {
+				childNode, childLayers, err := a.loadTree(ctx, watcher, pipeline, child, path)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
+				node.Children = append(node.Children, childNode)
+				layers = append(layers, childLayers...)
 			}
 		}
+	}
 
-		for name, configs := range conf.Inputs {
-			for _, config := range configs {
-				inputs, err := models.NewRunningInputs(name, config, a.registry)
-				if err != nil {
-					return nil, err
-				}
-				pipeline.AddInputs(inputs...)
+	return node, layers, nil
+}
+
+// checkAgentConfig rejects agent settings the scheduler cannot run with.
+func checkAgentConfig(agent *telegraf.AgentConfig) error {
+	if agent.Interval.Duration <= 0 {
+		return fmt.Errorf("agent: interval must be positive")
+	}
+	if agent.FlushInterval.Duration <= 0 {
+		return fmt.Errorf("agent: flush_interval must be positive")
+	}
+	return nil
+}
+
+// addPlugins creates the inputs, outputs, processors and aggregators of the
+// config and adds them to the pipeline.
+func (a *Agent) addPlugins(pipeline *Pipeline, conf *telegraf.Config) error {
+	for _, name := range sortedKeys(conf.Inputs) {
+		for _, config := range conf.Inputs[name] {
+			inputs, err := models.NewRunningInputs(name, config, a.registry)
+			if err != nil {
+				return err
 			}
+			pipeline.AddInputs(inputs...)
 		}
+	}
 
-		for name, configs := range conf.Outputs {
-			for _, config := range configs {
-				outputs, err := models.NewRunningOutputs(name, config, a.registry)
-				if err != nil {
-					return nil, err
-				}
-				pipeline.AddOutputs(outputs...)
+	for _, name := range sortedKeys(conf.Outputs) {
+		for _, config := range conf.Outputs[name] {
+			outputs, err := models.NewRunningOutputs(name, config, a.registry)
+			if err != nil {
+				return err
 			}
+			pipeline.AddOutputs(outputs...)
 		}
 	}
 
-	return pipeline, nil
+	var chain []*models.RunningProcessor
+	for _, name := range sortedKeys(conf.Processors) {
+		for _, config := range conf.Processors[name] {
+			processors, err := models.NewRunningProcessors(name, config, a.registry)
+			if err != nil {
+				return err
+			}
+			chain = append(chain, processors...)
+		}
+	}
+	pipeline.Processors = models.NewProcessorChain(chain)
+
+	for _, name := range sortedKeys(conf.Aggregators) {
+		for _, config := range conf.Aggregators[name] {
+			aggregators, err := models.NewRunningAggregators(name, config, a.registry)
+			if err != nil {
+				return err
+			}
+			pipeline.AddAggregators(aggregators...)
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a map with string keys in sorted order.
+func sortedKeys(m interface{}) []string {
+	keys := make([]string, 0)
+	for _, key := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 type watcher struct {
@@ -333,41 +676,30 @@ func (m *watcher) Wait() error {
 	return nil
 }
 
-// Restart triggers a plugin reload
+// Reload is a no-op, Run reloads the config each time a Loader reports a
+// change.
 func (a *Agent) Reload() error {
 	return nil
-
-	// Alternative way:
-	// load_new_configs()
-	// if error:
-	//    # note: includes errors on New
-	//    print error and abandon reload
-	//
-	// try connect new outputs
-	// if error:
-	//    print error and abandon reload
-	//
-	// swap_runtime_state()
-	// pause_inputs() # doesn't exist today -- future ack work
-	//
-	// async start new inputs()
-	//
-	// flush_aggregators()
-	// flush_processors()
-	// flush_outputs()
-	// run_input_acks() # doesn't exist today -- future ack work
-	// delete_old_runtime_state()
-
-	// Needs plenty of thought about what we do when the config cannot be
-	// loaded.
-
-	// Remain cancellable...
 }
 
 // Shutdown stops the Agent
 func (a *Agent) Shutdown() {
 }
 
+// FormatLoaderTree returns an indented listing of the Loader tree.
+func FormatLoaderTree(nodes []*LoaderNode) string {
+	var b bytes.Buffer
+	var format func(nodes []*LoaderNode, depth int)
+	format = func(nodes []*LoaderNode, depth int) {
+		for _, node := range nodes {
+			fmt.Fprintf(&b, "%s%s\n", strings.Repeat("  ", depth), node.Loader.ID)
+			format(node.Children, depth+1)
+		}
+	}
+	format(nodes, 0)
+	return b.String()
+}
+
 func FormatPlugin(p interface{}) string {
 	var b bytes.Buffer
 	enc := json.NewEncoder(&b)
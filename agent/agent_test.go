@@ -0,0 +1,131 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loaderNames returns the names of the Loaders in the tree, indented by
+// depth.
+func loaderNames(nodes []*LoaderNode, depth int) string {
+	var b strings.Builder
+	for _, node := range nodes {
+		fmt.Fprintf(&b, "%s%s\n", strings.Repeat("  ", depth), node.Loader.Name)
+		b.WriteString(loaderNames(node.Children, depth+1))
+	}
+	return b.String()
+}
+
+func TestLoadTree(t *testing.T) {
+	// Each file declares a toml Loader for every file it names.
+	tests := []struct {
+		name     string
+		files    map[string][]string
+		maxDepth int
+		want     string
+		wantErr  string
+	}{
+		{
+			name:  "single file",
+			files: map[string][]string{"main": nil},
+			want:  "defaults\ntoml\n",
+		},
+		{
+			name: "nested",
+			files: map[string][]string{
+				"main": {"a", "b"},
+				"a":    {"c"},
+				"b":    nil,
+				"c":    nil,
+			},
+			want: "defaults\ntoml\n  toml\n    toml\n  toml\n",
+		},
+		{
+			name: "same file twice is not a cycle",
+			files: map[string][]string{
+				"main": {"a", "b"},
+				"a":    {"c"},
+				"b":    {"c"},
+				"c":    nil,
+			},
+			want: "defaults\ntoml\n  toml\n    toml\n  toml\n    toml\n",
+		},
+		{
+			name:    "self cycle",
+			files:   map[string][]string{"main": {"a"}, "a": {"a"}},
+			wantErr: "loader cycle detected: ",
+		},
+		{
+			name: "indirect cycle",
+			files: map[string][]string{
+				"main": {"a"},
+				"a":    {"b"},
+				"b":    {"a"},
+			},
+			wantErr: "loader cycle detected: ",
+		},
+		{
+			name: "depth limit",
+			files: map[string][]string{
+				"main": {"a"},
+				"a":    {"b"},
+				"b":    nil,
+			},
+			maxDepth: 1,
+			wantErr:  "exceeds the maximum depth of 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "agent")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			for name, children := range tt.files {
+				var b strings.Builder
+				for _, child := range children {
+					fmt.Fprintf(&b, "[[loaders.toml]]\n  path = %q\n", filepath.Join(dir, child))
+				}
+				if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(b.String()), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			agent, err := NewAgent(&Flags{
+				Args:           []string{filepath.Join(dir, "main")},
+				MaxLoaderDepth: tt.maxDepth,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			watcher := NewWatcher()
+			defer func() {
+				cancel()
+				watcher.Wait()
+			}()
+
+			pipeline, err := agent.LoadPipeline(ctx, watcher)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadPipeline() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := loaderNames(pipeline.LoaderTree, 0); got != tt.want {
+				t.Errorf("loader tree =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"context"
+	"strings"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/models"
+	"github.com/influxdata/tgconfig/plugins/loaders/null"
+	"github.com/influxdata/tgconfig/plugins/loaders/toml"
+
+	// The default config uses the memstats input.
+	_ "github.com/influxdata/tgconfig/plugins/inputs/memstats"
+)
+
+// defaultsName is the name of the Loader of the built-in defaults.  It is not
+// in the Loader registry, so it cannot be declared by a config.
+const defaultsName = "defaults"
+
+// defaultConfig is compiled into the binary and is the base layer that all
+// user configs are overlaid on.  It reports the agent's own memory
+// statistics to stdout, and sets the documented agent defaults.
+const defaultConfig = `
+[agent]
+  interval = "10s"
+  round_interval = true
+  flush_interval = "10s"
+
+[[inputs.memstats]]
+
+[[outputs.file]]
+  files = ["stdout"]
+  data_format = "influx"
+`
+
+// defaultsLoader is a Loader that provides the built-in defaultConfig.
+type defaultsLoader struct {
+}
+
+// newDefaultsLoader creates the RunningLoader of the built-in defaults.
+func newDefaultsLoader() *models.RunningLoader {
+	return &models.RunningLoader{
+		Config: &telegraf.CommonLoaderConfig{Conflict: telegraf.ConflictAppend},
+		Loader: &defaultsLoader{},
+		Name:   defaultsName,
+		ID:     defaultsName,
+	}
+}
+
+func (l *defaultsLoader) Load(ctx context.Context, registry telegraf.ConfigRegistry) (*telegraf.Config, error) {
+	parser := toml.NewParser(registry)
+	return parser.Parse(strings.NewReader(defaultConfig))
+}
+
+// Watch never completes on its own, the built-in config cannot change.
+func (l *defaultsLoader) Watch(ctx context.Context) (telegraf.Waiter, error) {
+	return null.NewNullWaiter(ctx)
+}
@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/models"
+	"github.com/influxdata/tgconfig/plugins/outputs/file"
+)
+
+func TestDefaultsLoader(t *testing.T) {
+	registry := newTestRegistry(t)
+	conf, err := newDefaultsLoader().Load(context.Background(), registry.GetConfigRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *conf.Agent != telegraf.DefaultAgentConfig() {
+		t.Errorf("agent = %+v, want the documented defaults %+v",
+			*conf.Agent, telegraf.DefaultAgentConfig())
+	}
+	if len(conf.Inputs["memstats"]) != 1 {
+		t.Errorf("inputs = %v, want the memstats input", sortedKeys(conf.Inputs))
+	}
+	outputs := conf.Outputs["file"]
+	if len(outputs) != 1 {
+		t.Fatalf("outputs = %v, want the file output", sortedKeys(conf.Outputs))
+	}
+	if files := outputs[0].PluginConfig.(*file.Config).Files; len(files) != 1 || files[0] != "stdout" {
+		t.Errorf("file output files = %v, want [stdout]", files)
+	}
+}
+
+func TestDefaultsLoaderNotRegistered(t *testing.T) {
+	_, err := models.NewRunningLoaders(defaultsName, &telegraf.LoaderConfig{
+		Config: &telegraf.CommonLoaderConfig{},
+	}, newTestRegistry(t))
+	if err == nil {
+		t.Fatal("the defaults loader can be declared in a config")
+	}
+}
+
+func TestLoadPipelineDefaults(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "telegraf.conf")
+	user := "[agent]\n  flush_interval = \"5s\"\n\n[[inputs.example]]\n  value = \"user\"\n"
+	if err := ioutil.WriteFile(path, []byte(user), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantInputs []string
+		wantFlush  time.Duration
+	}{
+		{
+			name:       "no config file",
+			wantInputs: []string{"memstats"},
+			wantFlush:  10 * time.Second,
+		},
+		{
+			name:       "overlaid config file",
+			args:       []string{path},
+			wantInputs: []string{"example"},
+			wantFlush:  5 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent, err := NewAgent(&Flags{Args: tt.args})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			watcher := NewWatcher()
+			defer func() {
+				cancel()
+				watcher.Wait()
+			}()
+
+			pipeline, err := agent.LoadPipeline(ctx, watcher)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var inputs []string
+			for _, input := range pipeline.Inputs {
+				inputs = append(inputs, input.Name)
+			}
+			if strings.Join(inputs, ",") != strings.Join(tt.wantInputs, ",") {
+				t.Errorf("inputs = %v, want %v", inputs, tt.wantInputs)
+			}
+			if len(pipeline.Outputs) != 1 || pipeline.Outputs[0].Name != "file" {
+				t.Errorf("outputs = %d, want the default file output", len(pipeline.Outputs))
+			}
+			if pipeline.Agent.Interval.Duration != 10*time.Second {
+				t.Errorf("interval = %s, want 10s", pipeline.Agent.Interval.Duration)
+			}
+			if pipeline.Agent.FlushInterval.Duration != tt.wantFlush {
+				t.Errorf("flush_interval = %s, want %s", pipeline.Agent.FlushInterval.Duration, tt.wantFlush)
+			}
+		})
+	}
+}
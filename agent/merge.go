@@ -0,0 +1,199 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/models"
+)
+
+// layer is the config supplied by a single Loader.
+type layer struct {
+	loader *models.RunningLoader
+	config *telegraf.Config
+	// ancestors are the Loaders that declared the Loader, outermost first.
+	ancestors []*models.RunningLoader
+}
+
+// descends reports if the layer was loaded by a Loader declared, directly or
+// indirectly, in the config of the other layer.
+func (l *layer) descends(other *layer) bool {
+	for _, ancestor := range l.ancestors {
+		if ancestor == other.loader {
+			return true
+		}
+	}
+	return false
+}
+
+// merge combines the configs of all Loaders into a single Config.
+//
+// Layers are applied in ascending order of priority, with ties applied in
+// load order, so a Loader is applied after the Loader that declared it.  Each
+// agent setting is taken from the last layer that defines it.  At equal
+// priority a Loader may override the agent settings of the Loaders that
+// declared it, but two unrelated layers defining the same agent setting is an
+// error.
+//
+// Plugins are identified by their plugin name.  When a layer supplies a
+// plugin that an earlier layer already supplied, the conflict is an error if
+// either Loader's ConflictPolicy is ConflictError, and otherwise the policy
+// of the later layer's Loader decides the outcome.
+func merge(layers []*layer) (*telegraf.Config, error) {
+	sorted := make([]*layer, len(layers))
+	copy(sorted, layers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].loader.Config.Priority < sorted[j].loader.Config.Priority
+	})
+
+	merged := &telegraf.Config{
+		Inputs:      make(map[string][]*telegraf.InputConfig),
+		Outputs:     make(map[string][]*telegraf.OutputConfig),
+		Processors:  make(map[string][]*telegraf.ProcessorConfig),
+		Aggregators: make(map[string][]*telegraf.AggregatorConfig),
+	}
+
+	agentOwners := make(map[string]*layer)
+	inputOwners := make(map[string]*layer)
+	outputOwners := make(map[string]*layer)
+	processorOwners := make(map[string]*layer)
+	aggregatorOwners := make(map[string]*layer)
+
+	for _, l := range sorted {
+		if l.config.Agent != nil {
+			keys := agentKeys(l.config)
+			for _, key := range keys {
+				owner, ok := agentOwners[key]
+				if ok && owner.loader.Config.Priority == l.loader.Config.Priority &&
+					!l.descends(owner) {
+					return nil, fmt.Errorf(
+						"agent setting %s from loader %s conflicts with loader %s of the same priority",
+						key, l.loader.Name, owner.loader.Name)
+				}
+				agentOwners[key] = l
+			}
+
+			if merged.Agent == nil {
+				agent := telegraf.DefaultAgentConfig()
+				merged.Agent = &agent
+				merged.AgentKeys = make([]string, 0)
+			}
+			merged.Agent.Apply(l.config.Agent, keys)
+		}
+
+		for name, configs := range l.config.Inputs {
+			replace, err := resolve("input", name, inputOwners, l)
+			if err != nil {
+				return nil, err
+			}
+			if replace {
+				merged.Inputs[name] = nil
+			}
+			merged.Inputs[name] = append(merged.Inputs[name], configs...)
+		}
+
+		for name, configs := range l.config.Outputs {
+			replace, err := resolve("output", name, outputOwners, l)
+			if err != nil {
+				return nil, err
+			}
+			if replace {
+				merged.Outputs[name] = nil
+			}
+			merged.Outputs[name] = append(merged.Outputs[name], configs...)
+		}
+
+		for name, configs := range l.config.Processors {
+			replace, err := resolve("processor", name, processorOwners, l)
+			if err != nil {
+				return nil, err
+			}
+			if replace {
+				merged.Processors[name] = nil
+			}
+			merged.Processors[name] = append(merged.Processors[name], configs...)
+		}
+
+		for name, configs := range l.config.Aggregators {
+			replace, err := resolve("aggregator", name, aggregatorOwners, l)
+			if err != nil {
+				return nil, err
+			}
+			if replace {
+				merged.Aggregators[name] = nil
+			}
+			merged.Aggregators[name] = append(merged.Aggregators[name], configs...)
+		}
+	}
+
+	if merged.Agent != nil {
+		for _, key := range telegraf.AgentConfigKeys() {
+			if _, ok := agentOwners[key]; ok {
+				merged.AgentKeys = append(merged.AgentKeys, key)
+			}
+		}
+	}
+	return merged, nil
+}
+
+// agentKeys returns the keys of the agent settings a config defines.
+func agentKeys(conf *telegraf.Config) []string {
+	if conf.AgentKeys == nil {
+		return telegraf.AgentConfigKeys()
+	}
+	return conf.AgentKeys
+}
+
+// resolve applies the ConflictPolicy to a plugin and records the layer as
+// the latest to supply it.  It reports if the existing plugins should be
+// replaced.
+//
+// Only the latest earlier layer needs checking: had any layer before it used
+// ConflictError, the conflict between them would already have failed.
+func resolve(kind, name string, owners map[string]*layer, l *layer) (bool, error) {
+	owner, ok := owners[name]
+	owners[name] = l
+	if !ok {
+		return false, nil
+	}
+
+	if l.loader.Config.Conflict == telegraf.ConflictError ||
+		owner.loader.Config.Conflict == telegraf.ConflictError {
+		return false, fmt.Errorf("%s %s from loader %s conflicts with loader %s",
+			kind, name, l.loader.Name, owner.loader.Name)
+	}
+	return l.loader.Config.Conflict == telegraf.ConflictReplace, nil
+}
+
+// overlay places the merged user config over the built-in defaults.  A
+// plugin section of the defaults is only used when the user config does not
+// define any plugins in that section.  Each agent setting is taken from the
+// user config if it defines it, then from the defaults, falling back to
+// DefaultAgentConfig.
+func overlay(defaults *telegraf.Config, user *telegraf.Config) *telegraf.Config {
+	conf := *user
+
+	agent := telegraf.DefaultAgentConfig()
+	if defaults.Agent != nil {
+		agent.Apply(defaults.Agent, agentKeys(defaults))
+	}
+	if user.Agent != nil {
+		agent.Apply(user.Agent, agentKeys(user))
+	}
+	conf.Agent = &agent
+
+	if len(conf.Inputs) == 0 {
+		conf.Inputs = defaults.Inputs
+	}
+	if len(conf.Outputs) == 0 {
+		conf.Outputs = defaults.Outputs
+	}
+	if len(conf.Processors) == 0 {
+		conf.Processors = defaults.Processors
+	}
+	if len(conf.Aggregators) == 0 {
+		conf.Aggregators = defaults.Aggregators
+	}
+	return &conf
+}
@@ -0,0 +1,322 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/models"
+	"github.com/influxdata/tgconfig/plugins/aggregators"
+	"github.com/influxdata/tgconfig/plugins/inputs"
+	_ "github.com/influxdata/tgconfig/plugins/inputs/all"
+	"github.com/influxdata/tgconfig/plugins/inputs/example"
+	"github.com/influxdata/tgconfig/plugins/loaders"
+	"github.com/influxdata/tgconfig/plugins/loaders/toml"
+	"github.com/influxdata/tgconfig/plugins/outputs"
+	"github.com/influxdata/tgconfig/plugins/parsers"
+	"github.com/influxdata/tgconfig/plugins/processors"
+	"github.com/influxdata/tgconfig/plugins/serializers"
+)
+
+// testLayer describes a layer as the config loaded by a Loader.
+type testLayer struct {
+	name     string
+	priority int
+	conflict telegraf.ConflictPolicy
+	config   string
+	// parent is the name of the layer whose config declared the Loader.
+	parent string
+}
+
+func newTestRegistry(t *testing.T) telegraf.Registry {
+	t.Helper()
+	registry, err := models.NewRegistry(
+		loaders.Loaders,
+		inputs.Inputs,
+		outputs.Outputs,
+		parsers.Parsers,
+		processors.Processors,
+		aggregators.Aggregators,
+		serializers.Serializers,
+		loaders.Formats,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return registry
+}
+
+func parseConfig(t *testing.T, registry telegraf.Registry, config string) *telegraf.Config {
+	t.Helper()
+	conf, err := toml.NewParser(registry.GetConfigRegistry()).Parse(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conf
+}
+
+func newLayers(t *testing.T, registry telegraf.Registry, tls []testLayer) []*layer {
+	layers := make([]*layer, 0, len(tls))
+	byName := make(map[string]*layer)
+	for _, tl := range tls {
+		conflict := tl.conflict
+		if conflict == "" {
+			conflict = telegraf.ConflictAppend
+		}
+
+		var ancestors []*models.RunningLoader
+		if parent, ok := byName[tl.parent]; ok {
+			ancestors = append(append(ancestors, parent.ancestors...), parent.loader)
+		}
+
+		l := &layer{
+			loader: &models.RunningLoader{
+				Name: tl.name,
+				Config: &telegraf.CommonLoaderConfig{
+					Priority: tl.priority,
+					Conflict: conflict,
+				},
+			},
+			config:    parseConfig(t, registry, tl.config),
+			ancestors: ancestors,
+		}
+		layers = append(layers, l)
+		byName[tl.name] = l
+	}
+	return layers
+}
+
+func TestMergeAgent(t *testing.T) {
+	tests := []struct {
+		name    string
+		layers  []testLayer
+		want    telegraf.AgentConfig
+		wantErr bool
+	}{
+		{
+			name: "higher priority overrides only the keys it defines",
+			layers: []testLayer{
+				{name: "high", priority: 10, config: "[agent]\nflush_interval = \"1s\"\n"},
+				{name: "low", priority: 1, config: "[agent]\ninterval = \"5s\"\nflush_interval = \"3s\"\nround_interval = false\n"},
+			},
+			want: telegraf.AgentConfig{
+				Interval:      telegraf.Duration{Duration: 5 * time.Second},
+				FlushInterval: telegraf.Duration{Duration: time.Second},
+			},
+		},
+		{
+			name: "equal priority with different keys",
+			layers: []testLayer{
+				{name: "a", config: "[agent]\ninterval = \"5s\"\n"},
+				{name: "b", config: "[agent]\nflush_interval = \"2s\"\n"},
+			},
+			want: telegraf.AgentConfig{
+				Interval:      telegraf.Duration{Duration: 5 * time.Second},
+				RoundInterval: true,
+				FlushInterval: telegraf.Duration{Duration: 2 * time.Second},
+			},
+		},
+		{
+			name: "equal priority with the same key",
+			layers: []testLayer{
+				{name: "a", config: "[agent]\ninterval = \"5s\"\n"},
+				{name: "b", config: "[agent]\ninterval = \"6s\"\n"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "equal priority child overrides its parent",
+			layers: []testLayer{
+				{name: "main", config: "[agent]\ninterval = \"5s\"\n"},
+				{name: "sub", parent: "main", config: "[agent]\ninterval = \"6s\"\n"},
+				{name: "subsub", parent: "sub", config: "[agent]\ninterval = \"7s\"\n"},
+			},
+			want: telegraf.AgentConfig{
+				Interval:      telegraf.Duration{Duration: 7 * time.Second},
+				RoundInterval: true,
+				FlushInterval: telegraf.Duration{Duration: 10 * time.Second},
+			},
+		},
+		{
+			name: "equal priority siblings with the same key",
+			layers: []testLayer{
+				{name: "main"},
+				{name: "a", parent: "main", config: "[agent]\ninterval = \"5s\"\n"},
+				{name: "b", parent: "main", config: "[agent]\ninterval = \"6s\"\n"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "parent with higher priority keeps its setting",
+			layers: []testLayer{
+				{name: "main", priority: 1, config: "[agent]\ninterval = \"5s\"\n"},
+				{name: "sub", parent: "main", config: "[agent]\ninterval = \"6s\"\n"},
+			},
+			want: telegraf.AgentConfig{
+				Interval:      telegraf.Duration{Duration: 5 * time.Second},
+				RoundInterval: true,
+				FlushInterval: telegraf.Duration{Duration: 10 * time.Second},
+			},
+		},
+		{
+			name: "no agent settings",
+			layers: []testLayer{
+				{name: "a", config: "[[inputs.example]]\n"},
+			},
+			want: telegraf.DefaultAgentConfig(),
+		},
+	}
+
+	registry := newTestRegistry(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := merge(newLayers(t, registry, tt.layers))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			defaults := &telegraf.Config{}
+			got := overlay(defaults, merged).Agent
+			if *got != tt.want {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePlugins(t *testing.T) {
+	tests := []struct {
+		name    string
+		layers  []testLayer
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "append keeps both",
+			layers: []testLayer{
+				{name: "a", priority: 1, config: "[[inputs.example]]\nvalue = \"a\"\n"},
+				{name: "b", priority: 2, config: "[[inputs.example]]\nvalue = \"b\"\n"},
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "replace discards lower priority",
+			layers: []testLayer{
+				{name: "b", priority: 2, conflict: telegraf.ConflictReplace, config: "[[inputs.example]]\nvalue = \"b\"\n"},
+				{name: "a", priority: 1, config: "[[inputs.example]]\nvalue = \"a\"\n"},
+			},
+			want: []string{"b"},
+		},
+		{
+			name: "replace by lower priority is applied first",
+			layers: []testLayer{
+				{name: "a", priority: 1, conflict: telegraf.ConflictReplace, config: "[[inputs.example]]\nvalue = \"a\"\n"},
+				{name: "b", priority: 2, config: "[[inputs.example]]\nvalue = \"b\"\n"},
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "ties are applied in load order",
+			layers: []testLayer{
+				{name: "a", conflict: telegraf.ConflictReplace, config: "[[inputs.example]]\nvalue = \"a\"\n"},
+				{name: "b", conflict: telegraf.ConflictReplace, config: "[[inputs.example]]\nvalue = \"b\"\n"},
+			},
+			want: []string{"b"},
+		},
+		{
+			name: "error on conflict",
+			layers: []testLayer{
+				{name: "a", priority: 1, config: "[[inputs.example]]\nvalue = \"a\"\n"},
+				{name: "b", priority: 2, conflict: telegraf.ConflictError, config: "[[inputs.example]]\nvalue = \"b\"\n"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error on the earlier loader",
+			layers: []testLayer{
+				{name: "a", priority: 1, conflict: telegraf.ConflictError, config: "[[inputs.example]]\nvalue = \"a\"\n"},
+				{name: "b", priority: 2, conflict: telegraf.ConflictReplace, config: "[[inputs.example]]\nvalue = \"b\"\n"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "child replaces its parent",
+			layers: []testLayer{
+				{name: "main", config: "[[inputs.example]]\nvalue = \"main\"\n"},
+				{name: "sub", parent: "main", conflict: telegraf.ConflictReplace, config: "[[inputs.example]]\nvalue = \"sub\"\n"},
+			},
+			want: []string{"sub"},
+		},
+		{
+			name: "error without conflict",
+			layers: []testLayer{
+				{name: "a", priority: 1, config: "[[outputs.example]]\n"},
+				{name: "b", priority: 2, conflict: telegraf.ConflictError, config: "[[inputs.example]]\nvalue = \"b\"\n"},
+			},
+			want: []string{"b"},
+		},
+	}
+
+	registry := newTestRegistry(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := merge(newLayers(t, registry, tt.layers))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			var got []string
+			for _, config := range merged.Inputs["example"] {
+				got = append(got, config.PluginConfig.(*example.Config).Value)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	registry := newTestRegistry(t)
+	defaults := parseConfig(t, registry, `
+[agent]
+  interval = "20s"
+  flush_interval = "30s"
+
+[[inputs.example]]
+  value = "default"
+
+[[outputs.example]]
+  value = "default"
+`)
+	user := parseConfig(t, registry, `
+[agent]
+  flush_interval = "5s"
+
+[[inputs.example]]
+  value = "user"
+`)
+
+	conf := overlay(defaults, user)
+
+	want := telegraf.DefaultAgentConfig()
+	want.Interval.Duration = 20 * time.Second
+	want.FlushInterval.Duration = 5 * time.Second
+	if *conf.Agent != want {
+		t.Errorf("got agent %+v, want %+v", *conf.Agent, want)
+	}
+
+	if value := conf.Inputs["example"][0].PluginConfig.(*example.Config).Value; value != "user" {
+		t.Errorf("got input value %q, want %q", value, "user")
+	}
+	if len(conf.Outputs["example"]) != 1 {
+		t.Errorf("got %d outputs, want the default output", len(conf.Outputs["example"]))
+	}
+}
@@ -0,0 +1,256 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/models"
+)
+
+// gatherLoop gathers an input on its interval until the context is done.
+// A gather is skipped if the previous gather of the input has not yet
+// completed.  Returns once all gathers have completed.
+func gatherLoop(
+	ctx context.Context,
+	agent telegraf.AgentConfig,
+	input *models.RunningInput,
+	acc telegraf.Accumulator,
+) {
+	interval := agent.Interval.Duration
+	if input.Config.Interval.Duration > 0 {
+		interval = input.Config.Interval.Duration
+	}
+
+	timeout := agent.GatherTimeout.Duration
+	if timeout <= 0 {
+		timeout = interval
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	busy := make(chan struct{}, 1)
+
+	next := time.Now()
+	if agent.RoundInterval {
+		next = next.Truncate(interval)
+	}
+	next = next.Add(interval)
+
+	for {
+		if !sleep(ctx, time.Until(next)) {
+			return
+		}
+
+		// Skip any ticks that were missed.
+		now := time.Now()
+		for !next.After(now) {
+			next = next.Add(interval)
+		}
+
+		if !sleep(ctx, jitter(agent.CollectionJitter.Duration)) {
+			return
+		}
+
+		select {
+		case busy <- struct{}{}:
+		default:
+			fmt.Printf("W! [inputs.%s] previous gather has not completed, skipping collection\n",
+				input.Name)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-busy }()
+			gather(ctx, input, acc, timeout)
+		}()
+	}
+}
+
+// gather gathers the input once, warning if it exceeds the timeout.
+//
+// Once the context is done a gather that has exceeded its timeout is
+// abandoned, so that a hung Input cannot prevent the pipeline from
+// stopping.  An abandoned gather keeps running, but the metrics and errors
+// it adds are dropped.
+func gather(
+	ctx context.Context,
+	input *models.RunningInput,
+	acc telegraf.Accumulator,
+	timeout time.Duration,
+) {
+	gacc := &gatherAccumulator{acc: acc}
+	done := make(chan error, 1)
+	go func() {
+		done <- input.Input.Gather(gacc)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	expired := false
+	stopping := ctx.Done()
+	for {
+		select {
+		case err := <-done:
+			acc.AddError(err)
+			return
+		case <-timer.C:
+			expired = true
+			fmt.Printf("W! [inputs.%s] gather did not complete within %s\n",
+				input.Name, timeout)
+		case <-stopping:
+			stopping = nil
+		}
+
+		if expired && stopping == nil {
+			gacc.abandon()
+			fmt.Printf("W! [inputs.%s] abandoning gather that did not complete within %s\n",
+				input.Name, timeout)
+			return
+		}
+	}
+}
+
+// gatherAccumulator is the Accumulator of a single gather, it drops
+// everything added once the gather is abandoned.
+type gatherAccumulator struct {
+	acc telegraf.Accumulator
+
+	mu        sync.Mutex
+	abandoned bool
+}
+
+func (a *gatherAccumulator) AddFields(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	a.add(func() { a.acc.AddFields(measurement, fields, tags, t...) })
+}
+
+func (a *gatherAccumulator) AddGauge(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	a.add(func() { a.acc.AddGauge(measurement, fields, tags, t...) })
+}
+
+func (a *gatherAccumulator) AddCounter(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	a.add(func() { a.acc.AddCounter(measurement, fields, tags, t...) })
+}
+
+func (a *gatherAccumulator) AddMetric(m telegraf.Metric) {
+	a.add(func() { a.acc.AddMetric(m) })
+}
+
+func (a *gatherAccumulator) AddError(err error) {
+	a.add(func() { a.acc.AddError(err) })
+}
+
+// add forwards to the Accumulator unless the gather was abandoned.  The
+// lock is held while forwarding so that nothing is forwarded once abandon
+// returns.
+func (a *gatherAccumulator) add(fn func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.abandoned {
+		fn()
+	}
+}
+
+func (a *gatherAccumulator) abandon() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.abandoned = true
+}
+
+// sleep waits for the duration, returning false if the context is done
+// first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// jitter returns a random duration up to max.
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+// flushLoop writes an output on its flush interval, or sooner when a full
+// batch is ready, until the context is done.
+func flushLoop(
+	ctx context.Context,
+	agent telegraf.AgentConfig,
+	output *models.RunningOutput,
+) {
+	interval := agent.FlushInterval.Duration
+	if output.Config.FlushInterval.Duration > 0 {
+		interval = output.Config.FlushInterval.Duration
+	}
+
+	for {
+		timer := time.NewTimer(interval + jitter(agent.FlushJitter.Duration))
+		select {
+		case <-timer.C:
+		case <-output.BatchReady:
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+		flush(output)
+	}
+}
+
+// flush writes the buffered metrics of an output.
+func flush(output *models.RunningOutput) {
+	err := output.Write()
+	if err != nil {
+		fmt.Printf("E! [outputs.%s] error writing metrics: %v\n", output.Name, err)
+	}
+}
+
+// aggregateLoop pushes the aggregates of each period once the period and its
+// delay have passed, until the context is done.
+func aggregateLoop(
+	ctx context.Context,
+	aggregator *models.RunningAggregator,
+	acc telegraf.Accumulator,
+) {
+	for {
+		until := aggregator.EndPeriod().Add(aggregator.Config.Delay.Duration)
+		if !sleep(ctx, time.Until(until)) {
+			return
+		}
+		aggregator.Push(acc)
+	}
+}
@@ -0,0 +1,169 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/models"
+)
+
+// testAccumulator records what is added to it.
+type testAccumulator struct {
+	mu     sync.Mutex
+	fields []string
+	errors []error
+}
+
+func (a *testAccumulator) AddFields(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.fields = append(a.fields, measurement)
+}
+
+func (a *testAccumulator) AddGauge(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.AddFields(measurement, fields, tags, t...)
+}
+
+func (a *testAccumulator) AddCounter(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.AddFields(measurement, fields, tags, t...)
+}
+
+func (a *testAccumulator) AddMetric(m telegraf.Metric) {
+	a.AddFields(m.Name(), nil, nil)
+}
+
+func (a *testAccumulator) AddError(err error) {
+	if err == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.errors = append(a.errors, err)
+}
+
+func (a *testAccumulator) count() (int, int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.fields), len(a.errors)
+}
+
+// blockingInput adds a metric before and after waiting for release.
+type blockingInput struct {
+	release chan struct{}
+	err     error
+}
+
+func (i *blockingInput) Gather(acc telegraf.Accumulator) error {
+	acc.AddFields("before", map[string]interface{}{"v": 1}, nil)
+	<-i.release
+	acc.AddFields("after", map[string]interface{}{"v": 1}, nil)
+	return i.err
+}
+
+func TestGather(t *testing.T) {
+	tests := []struct {
+		name       string
+		cancel     bool
+		timeout    time.Duration
+		release    time.Duration
+		wantFields int
+		wantErrors int
+	}{
+		{
+			name:       "completes",
+			timeout:    time.Second,
+			release:    10 * time.Millisecond,
+			wantFields: 2,
+			wantErrors: 1,
+		},
+		{
+			name:       "completes past timeout while running",
+			timeout:    10 * time.Millisecond,
+			release:    50 * time.Millisecond,
+			wantFields: 2,
+			wantErrors: 1,
+		},
+		{
+			name:       "stopping waits for timeout",
+			cancel:     true,
+			timeout:    time.Second,
+			release:    20 * time.Millisecond,
+			wantFields: 2,
+			wantErrors: 1,
+		},
+		{
+			name:       "stopping abandons hung gather",
+			cancel:     true,
+			timeout:    20 * time.Millisecond,
+			wantFields: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := &blockingInput{release: make(chan struct{}), err: errors.New("failed")}
+			running := &models.RunningInput{Name: "test", Input: input}
+			acc := &testAccumulator{}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+			if tt.release > 0 {
+				time.AfterFunc(tt.release, func() { close(input.release) })
+			}
+
+			done := make(chan struct{})
+			go func() {
+				gather(ctx, running, acc, tt.timeout)
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("gather did not return")
+			}
+
+			if tt.release == 0 {
+				// The abandoned gather finishes after gather returned.
+				close(input.release)
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			fields, errs := acc.count()
+			if fields != tt.wantFields || errs != tt.wantErrors {
+				t.Errorf("got %d metrics and %d errors, want %d and %d",
+					fields, errs, tt.wantFields, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestCheckAgentConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*telegraf.AgentConfig)
+		wantErr bool
+	}{
+		{name: "defaults", modify: func(*telegraf.AgentConfig) {}},
+		{name: "zero interval", modify: func(c *telegraf.AgentConfig) { c.Interval.Duration = 0 }, wantErr: true},
+		{name: "negative interval", modify: func(c *telegraf.AgentConfig) { c.Interval.Duration = -time.Second }, wantErr: true},
+		{name: "zero flush interval", modify: func(c *telegraf.AgentConfig) { c.FlushInterval.Duration = 0 }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := telegraf.DefaultAgentConfig()
+			tt.modify(&config)
+			err := checkAgentConfig(&config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package telegraf
+
+// Aggregator is an aggregator plugin, it summarizes the metrics of each
+// period and emits the result at the end of the period.
+type Aggregator interface {
+	// Add adds a metric to the aggregates of the current period.
+	Add(in Metric)
+
+	// Push adds the aggregates of the current period to the Accumulator.
+	Push(acc Accumulator)
+
+	// Reset clears the aggregates, starting a new period.
+	Reset()
+}
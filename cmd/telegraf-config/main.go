@@ -0,0 +1,161 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/influxdata/tgconfig/internal/envelope"
+	"github.com/influxdata/tgconfig/internal/signature"
+)
+
+const usage = `usage: telegraf-config <command> [options]
+
+commands:
+  keygen -out <name>         create a signing key <name> and public key <name>.pub
+  sign -key <name> <config>  write the detached signature <config>.sig
+  enckey -out <name>         create an encryption key <name>
+  encrypt [-key <name>] [-key-env <var>] [-out <file>] <config>
+                             encrypt a config file
+  decrypt [-key <name>] [-key-env <var>] [-out <file>] <config>
+                             decrypt a config file
+
+When -key is not given the encryption key is read from the environment
+variable named by -key-env, by default ` + envelope.DefaultKeyEnv + `.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "keygen":
+		err = keygen(os.Args[2:])
+	case "sign":
+		err = sign(os.Args[2:])
+	case "enckey":
+		err = enckey(os.Args[2:])
+	case "encrypt":
+		err = crypt("encrypt", os.Args[2:], envelope.Encrypt)
+	case "decrypt":
+		err = crypt("decrypt", os.Args[2:], envelope.Decrypt)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func keygen(args []string) error {
+	flags := flag.NewFlagSet("keygen", flag.ExitOnError)
+	out := flags.String("out", "telegraf", "name of the key files")
+	flags.Parse(args)
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	err = writeBase64(*out, private, 0600)
+	if err != nil {
+		return err
+	}
+	return writeBase64(*out+".pub", public, 0644)
+}
+
+func sign(args []string) error {
+	flags := flag.NewFlagSet("sign", flag.ExitOnError)
+	keyfile := flags.String("key", "telegraf", "signing key file")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("sign: expected a single config file")
+	}
+	path := flags.Arg(0)
+
+	key, err := readBase64(*keyfile)
+	if err != nil {
+		return err
+	}
+	if len(key) != ed25519.PrivateKeySize {
+		return fmt.Errorf("sign: %s is not a signing key", *keyfile)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	sig := signature.Sign(ed25519.PrivateKey(key), data)
+	return ioutil.WriteFile(path+".sig", sig, 0644)
+}
+
+func enckey(args []string) error {
+	flags := flag.NewFlagSet("enckey", flag.ExitOnError)
+	out := flags.String("out", "telegraf.key", "name of the key file")
+	flags.Parse(args)
+
+	key, err := envelope.GenerateKey()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(*out, key, 0600)
+}
+
+// crypt encrypts or decrypts a config file, writing the result to stdout
+// unless an output file is given.
+func crypt(name string, args []string, fn func(key, data []byte) ([]byte, error)) error {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	keyfile := flags.String("key", "", "encryption key file")
+	keyenv := flags.String("key-env", "", "environment variable containing the encryption key")
+	out := flags.String("out", "", "output file, defaults to stdout")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("%s: expected a single config file", name)
+	}
+
+	key, err := envelope.ReadKey(*keyfile, *keyenv)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	result, err := fn(key, data)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(result)
+		return err
+	}
+	return ioutil.WriteFile(*out, result, 0600)
+}
+
+func readBase64(path string) ([]byte, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(string(buf)))
+}
+
+func writeBase64(path string, data []byte, perm os.FileMode) error {
+	encoded := base64.StdEncoding.EncodeToString(data) + "\n"
+	return ioutil.WriteFile(path, []byte(encoded), perm)
+}
@@ -14,6 +14,8 @@ import (
 
 var fDebug = flag.Bool("debug", false, "turn on debug logging")
 var fRunTimeout = flag.Int("run-timeout", 0, "run for this many seconds")
+var fMaxLoaderDepth = flag.Int("max-loader-depth", agent.DefaultMaxLoaderDepth,
+	"maximum nesting depth of loaders")
 
 func main() {
 	// Parse cli flags; these can never be modified, any other piece of
@@ -24,8 +26,9 @@ func main() {
 	args := flag.Args()
 
 	flags := &agent.Flags{
-		Debug: *fDebug,
-		Args:  args,
+		Debug:          *fDebug,
+		Args:           args,
+		MaxLoaderDepth: *fMaxLoaderDepth,
 	}
 
 	fmt.Println(*fRunTimeout)
@@ -1,5 +1,14 @@
 package telegraf
 
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // PluginType is an enum of the different plugin types.
 type PluginType int
 
@@ -8,17 +17,160 @@ const (
 	OutputType
 	LoaderType
 	ParserType
+	ProcessorType
+	AggregatorType
+	SerializerType
 )
 
 // AgentConfig contains the Agent configuration
 type AgentConfig struct {
-	Interval int `toml:"interval"`
+	// Interval is the default gather interval; defaults to 10s.
+	Interval Duration `toml:"interval"`
+	// RoundInterval aligns gathering to multiples of the interval, so an
+	// interval of 10s gathers at :00, :10, :20; defaults to true.
+	RoundInterval bool `toml:"round_interval"`
+	// CollectionJitter delays each gather by a random amount up to this
+	// duration; defaults to 0.
+	CollectionJitter Duration `toml:"collection_jitter"`
+	// GatherTimeout is how long a gather may run before a warning is
+	// reported; defaults to the gather interval of the input.  A gather
+	// still running past its timeout when the agent stops or reloads is
+	// abandoned, and any metrics it adds afterwards are dropped.
+	GatherTimeout Duration `toml:"gather_timeout"`
+	// FlushInterval is the default interval for writing to the outputs;
+	// defaults to 10s.
+	FlushInterval Duration `toml:"flush_interval"`
+	// FlushJitter delays each flush by a random amount up to this duration;
+	// defaults to 0.
+	FlushJitter Duration `toml:"flush_jitter"`
+}
+
+// DefaultAgentConfig returns the AgentConfig used for any settings that are
+// not set by a loaded config.
+func DefaultAgentConfig() AgentConfig {
+	return AgentConfig{
+		Interval:      Duration{10 * time.Second},
+		RoundInterval: true,
+		FlushInterval: Duration{10 * time.Second},
+	}
+}
+
+// Duration is a time.Duration that can be decoded from a string such as
+// "10s" or from an integer number of seconds.
+type Duration struct {
+	Duration time.Duration
+}
+
+// UnmarshalText parses the Duration.
+func (d *Duration) UnmarshalText(text []byte) error {
+	s := string(text)
+	if seconds, err := strconv.ParseInt(s, 10, 64); err == nil {
+		d.Duration = time.Duration(seconds) * time.Second
+		return nil
+	}
+
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = duration
+	return nil
+}
+
+// Size is a number of bytes that can be decoded from a string such as
+// "10MB" or "1GiB" or from an integer number of bytes.
+type Size struct {
+	Size int64
+}
+
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"kb":  1000,
+	"mb":  1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+}
+
+// UnmarshalText parses the Size.
+func (s *Size) UnmarshalText(text []byte) error {
+	str := strings.TrimSpace(string(text))
+	i := strings.IndexFunc(str, func(r rune) bool { return r < '0' || r > '9' })
+	if i < 0 {
+		i = len(str)
+	}
+
+	n, err := strconv.ParseInt(str[:i], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid size: %q", str)
+	}
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(str[i:]))]
+	if !ok {
+		return fmt.Errorf("invalid size: %q", str)
+	}
+	if n > math.MaxInt64/unit {
+		return fmt.Errorf("size out of range: %q", str)
+	}
+	s.Size = n * unit
+	return nil
+}
+
+// AgentConfigKeys returns the keys of all agent settings.
+func AgentConfigKeys() []string {
+	t := reflect.TypeOf(AgentConfig{})
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		keys = append(keys, t.Field(i).Tag.Get("toml"))
+	}
+	return keys
+}
+
+// Apply sets the agent settings named by keys to their values in other.
+func (c *AgentConfig) Apply(other *AgentConfig, keys []string) {
+	dst := reflect.ValueOf(c).Elem()
+	src := reflect.ValueOf(other).Elem()
+	for _, key := range keys {
+		for i := 0; i < dst.NumField(); i++ {
+			if dst.Type().Field(i).Tag.Get("toml") == key {
+				dst.Field(i).Set(src.Field(i))
+			}
+		}
+	}
 }
 
 // FilterConfig contains the standard filtering configuration.  We may need
 // one of these for each of inputs, processors, aggregators, outputs.
+//
+// Name, field and tag key patterns are globs.
 type FilterConfig struct {
-	NameOverride string `toml:"name_override"`
+	NameOverride string            `toml:"name_override"`
+	NamePrefix   string            `toml:"name_prefix"`
+	NameSuffix   string            `toml:"name_suffix"`
+	Tags         map[string]string `toml:"tags"`
+
+	SelectConfig
+
+	// FieldPass and FieldDrop select the fields of a metric by key.
+	FieldPass []string `toml:"fieldpass"`
+	FieldDrop []string `toml:"fielddrop"`
+
+	// TagInclude and TagExclude select the tags of a metric by key.
+	TagInclude []string `toml:"taginclude"`
+	TagExclude []string `toml:"tagexclude"`
+}
+
+// SelectConfig is the part of the FilterConfig that selects whole metrics.
+// Name and tag value patterns are globs.
+type SelectConfig struct {
+	// NamePass and NameDrop select metrics by measurement name.
+	NamePass []string `toml:"namepass"`
+	NameDrop []string `toml:"namedrop"`
+
+	// TagPass and TagDrop select metrics by tag value, keyed by tag key.
+	TagPass map[string][]string `toml:"tagpass"`
+	TagDrop map[string][]string `toml:"tagdrop"`
 }
 
 // ParserConfig is the shared configuration for Parsers.
@@ -26,19 +178,141 @@ type ParserConfig struct {
 	DataFormat string `toml:"data_format"`
 }
 
+// ParserSelector chooses the sources of data that a named Parser of an
+// Input is used for.  The patterns are globs, the Parser is selected when
+// any pattern matches.
+type ParserSelector struct {
+	// ContentTypes match the media type of the data, such as the
+	// Content-Type header of an HTTP request, without any parameters.
+	ContentTypes []string `toml:"content_types"`
+	// Topics match the message bus topic the data was received on.
+	Topics []string `toml:"topics"`
+	// FileExtensions match the extension, including the leading dot, of
+	// the file the data was read from.
+	FileExtensions []string `toml:"file_extensions"`
+}
+
+// CommonNamedParserConfig is the configuration options that can be set on
+// any named Parser.
+type CommonNamedParserConfig struct {
+	ParserConfig
+	ParserSelector
+
+	// Order is the position of the Parser among the named Parsers of the
+	// Input, Parsers with a lower Order are tried first.  Parsers with the
+	// same Order are tried in order of their names.
+	Order int `toml:"order"`
+}
+
+// SerializerConfig is the shared configuration for Serializers.
+type SerializerConfig struct {
+	DataFormat string `toml:"data_format"`
+}
+
 // CommonInputConfig is the configuration options that can be set on any Input.
 type CommonInputConfig struct {
 	FilterConfig
 	ParserConfig
+
+	// Interval overrides the agent interval for this Input, zero uses the
+	// agent interval.
+	Interval Duration `toml:"interval"`
 }
 
 // CommonOutputConfig is the configuration options that can be set on any Output.
 type CommonOutputConfig struct {
 	FilterConfig
+	SerializerConfig
+
+	// MetricBufferLimit is the maximum number of metrics buffered for the
+	// Output, when exceeded the oldest metrics are dropped.
+	MetricBufferLimit int `toml:"metric_buffer_limit"`
+	// MetricBatchSize is the maximum number of metrics in each write.
+	MetricBatchSize int `toml:"metric_batch_size"`
+	// FlushInterval overrides the agent flush interval for this Output,
+	// zero uses the agent flush interval.
+	FlushInterval Duration `toml:"flush_interval"`
+}
+
+// ConflictPolicy controls what happens when a Loader supplies a plugin with
+// the same name as a plugin supplied by a Loader applied before it, one of
+// lower priority or that declared it.  ConflictError on either Loader fails
+// loading, otherwise the policy of the Loader applied last is used.
+type ConflictPolicy string
+
+const (
+	// ConflictAppend keeps the plugins from both Loaders.
+	ConflictAppend ConflictPolicy = "append"
+	// ConflictReplace discards the plugins from the Loader applied before.
+	ConflictReplace ConflictPolicy = "replace"
+	// ConflictError fails loading.
+	ConflictError ConflictPolicy = "error"
+)
+
+// CommonProcessorConfig is the configuration options that can be set on any
+// Processor.  The SelectConfig selects the metrics passed to the Processor.
+type CommonProcessorConfig struct {
+	SelectConfig
+
+	// Order is the position of the Processor in the chain, Processors with
+	// a lower Order are applied first.
+	Order int `toml:"order"`
+}
+
+// CommonAggregatorConfig is the configuration options that can be set on any
+// Aggregator.  The FilterConfig selects the metrics added to the Aggregator,
+// its name modifiers and tags are applied to the aggregates.
+type CommonAggregatorConfig struct {
+	FilterConfig
+
+	// Period is the length of each aggregation window; defaults to 30s.
+	Period Duration `toml:"period"`
+	// Delay is how long to wait after the end of a period before pushing
+	// the aggregates, allowing for late metrics; defaults to 100ms.
+	Delay Duration `toml:"delay"`
+	// Grace accepts metrics with a timestamp up to this long before the
+	// start of the current period; defaults to 0.
+	Grace Duration `toml:"grace"`
+	// DropOriginal prevents the metrics added to the Aggregator from being
+	// sent to the Outputs.
+	DropOriginal bool `toml:"drop_original"`
 }
 
 // CommonLoaderConfig is the configuration options that can be set on any Loader.
 type CommonLoaderConfig struct {
+	// Priority orders the Loaders when their configs are merged, higher
+	// priorities are applied last and take precedence.  At equal priority a
+	// Loader takes precedence over the Loader that declared it.
+	Priority int `toml:"priority"`
+	// Conflict is the ConflictPolicy for plugins supplied by this Loader,
+	// defaults to ConflictAppend.
+	Conflict ConflictPolicy `toml:"conflict"`
+	// Policy restricts what the Loader may supply, when nil the Loader is
+	// unrestricted.
+	Policy *LoaderPolicy `toml:"policy"`
+	// Verify enables signature verification of the config, when nil configs
+	// are not verified.
+	Verify *VerifyConfig `toml:"verify"`
+}
+
+// VerifyConfig lists the trusted ed25519 public keys used to verify the
+// signature of a Loader's config.  Keys are base64 encoded.
+type VerifyConfig struct {
+	PublicKeys     []string `toml:"public_keys"`
+	PublicKeyFiles []string `toml:"public_key_files"`
+}
+
+// LoaderPolicy lists what a Loader is permitted to supply, anything not
+// listed is denied.  Plugin names are glob patterns, so "*" permits all
+// plugins of a type.
+type LoaderPolicy struct {
+	Inputs      []string `toml:"inputs"`
+	Outputs     []string `toml:"outputs"`
+	Loaders     []string `toml:"loaders"`
+	Processors  []string `toml:"processors"`
+	Aggregators []string `toml:"aggregators"`
+	// Agent permits the Loader to modify the agent settings.
+	Agent bool `toml:"agent"`
 }
 
 // PluginConfig is a config struct for plugin.
@@ -51,12 +325,44 @@ type PluginFactory = interface{}
 type InputConfig struct {
 	Config       *CommonInputConfig
 	PluginConfig PluginConfig
+	// ParserConfig is the configuration of the Parser for
+	// Config.DataFormat.
+	ParserConfig PluginConfig
+	// Parsers are the named Parsers, sorted by name.  They are tried by
+	// their Order before falling back to the Parser for Config.DataFormat.
+	Parsers []*NamedParserConfig
+	// ParserOptions are the names of the parser options present in the config,
+	// these are an error on Inputs without a Parser.
+	ParserOptions []string
+}
+
+// NamedParserConfig is all configuration needed to create a named Parser of
+// an Input.
+type NamedParserConfig struct {
+	Name         string
+	Config       *CommonNamedParserConfig
 	ParserConfig PluginConfig
 }
 
 // OutputConfig is all configuration needed to create the Outputs.
 type OutputConfig struct {
-	Config       *CommonOutputConfig
+	Config           *CommonOutputConfig
+	PluginConfig     PluginConfig
+	SerializerConfig PluginConfig
+	// SerializerOptions are the names of the serializer options present in
+	// the config, these are an error on Outputs without a Serializer.
+	SerializerOptions []string
+}
+
+// ProcessorConfig is all configuration needed to create the Processors.
+type ProcessorConfig struct {
+	Config       *CommonProcessorConfig
+	PluginConfig PluginConfig
+}
+
+// AggregatorConfig is all configuration needed to create the Aggregators.
+type AggregatorConfig struct {
+	Config       *CommonAggregatorConfig
 	PluginConfig PluginConfig
 }
 
@@ -68,10 +374,18 @@ type LoaderConfig struct {
 
 // Config is the full set of loadable configuration.
 type Config struct {
-	Agent   AgentConfig
-	Inputs  map[string][]*InputConfig
-	Outputs map[string][]*OutputConfig
-	Loaders map[string][]*LoaderConfig
+	// Agent is nil when the config does not contain agent settings.
+	Agent *AgentConfig
+	// AgentKeys are the keys of the agent settings the config defines, the
+	// other settings of Agent are defaults.  When nil all of the settings
+	// of Agent are defined.
+	AgentKeys []string
+
+	Inputs      map[string][]*InputConfig
+	Outputs     map[string][]*OutputConfig
+	Processors  map[string][]*ProcessorConfig
+	Aggregators map[string][]*AggregatorConfig
+	Loaders     map[string][]*LoaderConfig
 }
 
 // Registry is an interface for creating known plugins.
@@ -79,13 +393,18 @@ type Registry interface {
 	CreateInputs(name string, c PluginConfig) ([]Input, error)
 	CreateOutputs(name string, c PluginConfig) ([]Output, error)
 	CreateLoaders(name string, c PluginConfig) ([]Loader, error)
+	CreateProcessors(name string, c PluginConfig) ([]Processor, error)
+	CreateAggregators(name string, c PluginConfig) ([]Aggregator, error)
 
 	CreateParser(name string, c PluginConfig) (Parser, error)
+	CreateSerializer(name string, c PluginConfig) (Serializer, error)
 
 	GetConfigRegistry() ConfigRegistry
 }
 
-// ConfigRegistry is an interface that can create empty config structs.
+// ConfigRegistry is an interface that can create empty config structs, and
+// the ConfigParsers of config formats.
 type ConfigRegistry interface {
 	GetPluginConfig(pluginType PluginType, name string) (PluginConfig, bool)
+	GetConfigParser(format string) (ConfigParser, bool)
 }
@@ -0,0 +1,69 @@
+package telegraf
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDurationUnmarshalText(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    time.Duration
+		wantErr bool
+	}{
+		{text: "10s", want: 10 * time.Second},
+		{text: "1m30s", want: 90 * time.Second},
+		{text: "15", want: 15 * time.Second},
+		{text: "0", want: 0},
+		{text: "-2s", want: -2 * time.Second},
+		{text: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			var d Duration
+			err := d.UnmarshalText([]byte(tt.text))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && d.Duration != tt.want {
+				t.Errorf("got %s, want %s", d.Duration, tt.want)
+			}
+		})
+	}
+}
+
+func TestSizeUnmarshalText(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    int64
+		wantErr bool
+	}{
+		{text: "1024", want: 1024},
+		{text: "512B", want: 512},
+		{text: "10kB", want: 10 * 1000},
+		{text: "10MB", want: 10 * 1000 * 1000},
+		{text: "2 GB", want: 2 * 1000 * 1000 * 1000},
+		{text: "1KiB", want: 1024},
+		{text: "3mib", want: 3 << 20},
+		{text: "1GiB", want: 1 << 30},
+		{text: "1XB", wantErr: true},
+		{text: "MB", wantErr: true},
+		{text: "-1MB", wantErr: true},
+		{text: "9999999999GB", wantErr: true},
+		{text: "99999999999999999999", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			var s Size
+			err := s.UnmarshalText([]byte(tt.text))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && s.Size != tt.want {
+				t.Errorf("got %d, want %d", s.Size, tt.want)
+			}
+		})
+	}
+}
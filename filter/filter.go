@@ -0,0 +1,83 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Filter matches strings against a set of glob patterns.
+//
+// Existing: filter/filter.go Filter
+type Filter interface {
+	Match(s string) bool
+}
+
+type filter struct {
+	re *regexp.Regexp
+}
+
+// Compile compiles glob patterns into a Filter that matches a string if any
+// of the patterns match.  A nil Filter is returned when there are no
+// patterns.
+//
+// Patterns support "*" to match any sequence of characters, "?" to match a
+// single character and "[...]" to match a character class, negated by a
+// leading "!".
+func Compile(patterns []string) (Filter, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	exprs := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		expr, err := globToRegexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+
+	re, err := regexp.Compile("^(?:" + strings.Join(exprs, "|") + ")$")
+	if err != nil {
+		return nil, err
+	}
+	return &filter{re: re}, nil
+}
+
+func (f *filter) Match(s string) bool {
+	return f.re.MatchString(s)
+}
+
+func globToRegexp(pattern string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("invalid glob pattern %q: unterminated character class", pattern)
+			}
+			class := pattern[i+1 : i+1+end]
+			if class == "" || class == "!" {
+				return "", fmt.Errorf("invalid glob pattern %q: empty character class", pattern)
+			}
+
+			b.WriteByte('[')
+			if class[0] == '!' {
+				b.WriteByte('^')
+				class = class[1:]
+			}
+			b.WriteString(strings.NewReplacer(`\`, `\\`, `[`, `\[`, `^`, `\^`).Replace(class))
+			b.WriteByte(']')
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String(), nil
+}
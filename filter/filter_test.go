@@ -0,0 +1,96 @@
+package filter
+
+import (
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		match    []string
+		nomatch  []string
+	}{
+		{
+			name:     "literal",
+			patterns: []string{"cpu"},
+			match:    []string{"cpu"},
+			nomatch:  []string{"cpu0", "xcpu", ""},
+		},
+		{
+			name:     "star",
+			patterns: []string{"cpu*"},
+			match:    []string{"cpu", "cpu0", "cpu_total"},
+			nomatch:  []string{"mem"},
+		},
+		{
+			name:     "question mark",
+			patterns: []string{"cpu?"},
+			match:    []string{"cpu0"},
+			nomatch:  []string{"cpu", "cpu10"},
+		},
+		{
+			name:     "class",
+			patterns: []string{"sd[ab]"},
+			match:    []string{"sda", "sdb"},
+			nomatch:  []string{"sdc"},
+		},
+		{
+			name:     "negated class",
+			patterns: []string{"sd[!ab]"},
+			match:    []string{"sdc"},
+			nomatch:  []string{"sda"},
+		},
+		{
+			name:     "regexp metacharacters",
+			patterns: []string{"a.b+(c)"},
+			match:    []string{"a.b+(c)"},
+			nomatch:  []string{"axbb(c)"},
+		},
+		{
+			name:     "any pattern",
+			patterns: []string{"cpu", "mem*"},
+			match:    []string{"cpu", "memory"},
+			nomatch:  []string{"disk"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Compile(tt.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tt.match {
+				if !f.Match(s) {
+					t.Errorf("%q does not match %q", tt.patterns, s)
+				}
+			}
+			for _, s := range tt.nomatch {
+				if f.Match(s) {
+					t.Errorf("%q matches %q", tt.patterns, s)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []string{"[", "a[]", "[!]"}
+	for _, pattern := range tests {
+		t.Run(pattern, func(t *testing.T) {
+			if _, err := Compile([]string{pattern}); err == nil {
+				t.Fatalf("expected error for %q", pattern)
+			}
+		})
+	}
+}
+
+func TestCompileEmpty(t *testing.T) {
+	f, err := Compile(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f != nil {
+		t.Fatal("expected nil Filter for no patterns")
+	}
+}
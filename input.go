@@ -2,11 +2,39 @@ package telegraf
 
 // Input is an input plugin.
 type Input interface {
-	Gather() error
+	// Gather adds the current metrics to the Accumulator.
+	Gather(acc Accumulator) error
 }
 
-// plugins/parsers/registry.go
+// ParserSource describes where data came from, used to select the Parser
+// for it.  Fields that do not apply are left empty.
+type ParserSource struct {
+	// ContentType is the media type of the data, such as the Content-Type
+	// header of an HTTP request.
+	ContentType string
+	// Topic is the message bus topic the data was received on.
+	Topic string
+	// Path is the file the data was read from.
+	Path string
+}
+
+// ParserFunc returns the Parser for data from the source.  It always
+// returns a Parser, falling back to the default for the Input.
+type ParserFunc func(source ParserSource) Parser
+
+// ParserInput is an Input that parses data with the parsers configured by
+// data_format and the named parsers.
+//
+// Existing: plugins/parsers/registry.ParserInput
 type ParserInput interface {
-	// SetParser sets the parser function for the interface
-	SetParser(parser Parser)
+	// SetParserFunc sets the function used to select the Parser.
+	SetParserFunc(fn ParserFunc)
+}
+
+// DataFormatInput is a ParserInput that chooses the data format used when
+// data_format is not set.  Without it data_format is required.
+type DataFormatInput interface {
+	ParserInput
+	// DataFormat returns the default data format of the Input.
+	DataFormat() string
 }
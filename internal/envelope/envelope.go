@@ -0,0 +1,143 @@
+package envelope
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const (
+	// BlockType is the PEM block type of an encrypted config.
+	BlockType = "TELEGRAF ENCRYPTED CONFIG"
+
+	// DefaultKeyEnv is the environment variable holding the key when no
+	// key file or variable is configured.
+	DefaultKeyEnv = "TELEGRAF_CONFIG_KEY"
+
+	// KeySize is the size of the AES-256 key.
+	KeySize = 32
+)
+
+var header = []byte("-----BEGIN " + BlockType + "-----")
+
+// IsEncrypted reports if data is an encrypted config envelope.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), header)
+}
+
+// Encrypt seals the plaintext with AES-256-GCM and returns it as a PEM
+// encoded envelope.
+func Encrypt(key, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	block := &pem.Block{
+		Type:  BlockType,
+		Bytes: aead.Seal(nonce, nonce, plaintext, nil),
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// Decrypt opens an envelope created by Encrypt.
+func Decrypt(key, data []byte) ([]byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != BlockType {
+		return nil, errors.New("not an encrypted config")
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(block.Bytes) < aead.NonceSize() {
+		return nil, errors.New("encrypted config is truncated")
+	}
+	nonce, ciphertext := block.Bytes[:aead.NonceSize()], block.Bytes[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("unable to decrypt config: wrong key or corrupted data")
+	}
+	return plaintext, nil
+}
+
+// Open returns data unchanged if it is not encrypted, otherwise it is
+// decrypted using the key read by ReadKey.
+func Open(data []byte, keyFile, keyEnv string) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+
+	key, err := ReadKey(keyFile, keyEnv)
+	if err != nil {
+		return nil, err
+	}
+	return Decrypt(key, data)
+}
+
+// ReadKey reads a base64 encoded key from keyFile, or when keyFile is empty
+// from the environment variable keyEnv, defaulting to DefaultKeyEnv.
+func ReadKey(keyFile, keyEnv string) ([]byte, error) {
+	var encoded string
+	if keyFile != "" {
+		buf, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		encoded = string(buf)
+	} else {
+		if keyEnv == "" {
+			keyEnv = DefaultKeyEnv
+		}
+
+		var ok bool
+		encoded, ok = os.LookupEnv(keyEnv)
+		if !ok {
+			return nil, fmt.Errorf("config is encrypted but %s is not set", keyEnv)
+		}
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %v", err)
+	}
+	return key, nil
+}
+
+// GenerateKey returns a new random base64 encoded key.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(key) + "\n"), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid encryption key: wrong size %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
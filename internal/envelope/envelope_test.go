@@ -0,0 +1,131 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+func testKey(t *testing.T) []byte {
+	t.Helper()
+	encoded, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	key := testKey(t)
+	plaintext := []byte("[agent]\n  interval = \"10s\"\n")
+
+	sealed, err := Encrypt(key, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(sealed) {
+		t.Fatal("sealed config is not detected as encrypted")
+	}
+	if bytes.Contains(sealed, []byte("interval")) {
+		t.Fatal("sealed config contains the plaintext")
+	}
+
+	got, err := Decrypt(key, sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("Decrypt() = %q, want %q", got, plaintext)
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	key := testKey(t)
+	sealed, err := Encrypt(key, []byte("config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ := pem.Decode(sealed)
+	tampered := append([]byte(nil), block.Bytes...)
+	tampered[len(tampered)-1] ^= 1
+	truncated := block.Bytes[:4]
+
+	tests := []struct {
+		name    string
+		key     []byte
+		data    []byte
+		wantErr string
+	}{
+		{
+			name:    "wrong key",
+			key:     testKey(t),
+			data:    sealed,
+			wantErr: "wrong key or corrupted data",
+		},
+		{
+			name:    "short key",
+			key:     key[:16],
+			data:    sealed,
+			wantErr: "wrong size 16",
+		},
+		{
+			name:    "tampered",
+			key:     key,
+			data:    pem.EncodeToMemory(&pem.Block{Type: BlockType, Bytes: tampered}),
+			wantErr: "wrong key or corrupted data",
+		},
+		{
+			name:    "truncated",
+			key:     key,
+			data:    pem.EncodeToMemory(&pem.Block{Type: BlockType, Bytes: truncated}),
+			wantErr: "truncated",
+		},
+		{
+			name:    "other block type",
+			key:     key,
+			data:    pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: block.Bytes}),
+			wantErr: "not an encrypted config",
+		},
+		{
+			name:    "plaintext",
+			key:     key,
+			data:    []byte("[agent]\n"),
+			wantErr: "not an encrypted config",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decrypt(tt.key, tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Decrypt() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsEncrypted(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want bool
+	}{
+		{name: "envelope", data: "-----BEGIN " + BlockType + "-----\n", want: true},
+		{name: "leading space", data: "\n  -----BEGIN " + BlockType + "-----\n", want: true},
+		{name: "toml", data: "[agent]\n"},
+		{name: "empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsEncrypted([]byte(tt.data)); got != tt.want {
+				t.Errorf("IsEncrypted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+var (
+	ErrMissingSignature = errors.New("config is not signed")
+	ErrInvalidSignature = errors.New("config signature is not valid")
+)
+
+// Verifier checks ed25519 signatures against a set of trusted public keys.
+type Verifier struct {
+	keys []ed25519.PublicKey
+}
+
+// NewVerifier creates a Verifier from the public keys in the config.
+func NewVerifier(config *telegraf.VerifyConfig) (*Verifier, error) {
+	encoded := make([]string, 0, len(config.PublicKeys))
+	encoded = append(encoded, config.PublicKeys...)
+	for _, path := range config.PublicKeyFiles {
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, string(buf))
+	}
+
+	if len(encoded) == 0 {
+		return nil, errors.New("no public keys configured for verification")
+	}
+
+	v := &Verifier{}
+	for _, s := range encoded {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %v", err)
+		}
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid public key: wrong size %d", len(key))
+		}
+		v.keys = append(v.keys, ed25519.PublicKey(key))
+	}
+	return v, nil
+}
+
+// Verify checks that the signature over data was made by one of the trusted
+// keys.  The signature may be raw or base64 encoded.
+func (v *Verifier) Verify(data, signature []byte) error {
+	sig, err := decode(signature)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range v.keys {
+		if ed25519.Verify(key, data, sig) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+func decode(signature []byte) ([]byte, error) {
+	if len(signature) == 0 {
+		return nil, ErrMissingSignature
+	}
+	if len(signature) == ed25519.SignatureSize {
+		return signature, nil
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(signature)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return nil, ErrInvalidSignature
+	}
+	return sig, nil
+}
+
+// Sign returns the base64 encoded signature of data.
+func Sign(key ed25519.PrivateKey, data []byte) []byte {
+	sig := ed25519.Sign(key, data)
+	return []byte(base64.StdEncoding.EncodeToString(sig) + "\n")
+}
@@ -0,0 +1,143 @@
+package signature
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+func generateKey(t *testing.T) (string, ed25519.PrivateKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(public), private
+}
+
+func TestVerify(t *testing.T) {
+	trusted, key := generateKey(t)
+	other, otherKey := generateKey(t)
+	data := []byte("[agent]\n  interval = \"10s\"\n")
+
+	tests := []struct {
+		name      string
+		keys      []string
+		signature []byte
+		want      error
+	}{
+		{
+			name:      "base64",
+			keys:      []string{trusted},
+			signature: Sign(key, data),
+		},
+		{
+			name:      "raw",
+			keys:      []string{trusted},
+			signature: ed25519.Sign(key, data),
+		},
+		{
+			name:      "second key",
+			keys:      []string{other, trusted},
+			signature: Sign(key, data),
+		},
+		{
+			name:      "untrusted key",
+			keys:      []string{trusted},
+			signature: Sign(otherKey, data),
+			want:      ErrInvalidSignature,
+		},
+		{
+			name:      "other data",
+			keys:      []string{trusted},
+			signature: Sign(key, []byte("[agent]\n")),
+			want:      ErrInvalidSignature,
+		},
+		{
+			name: "missing",
+			keys: []string{trusted},
+			want: ErrMissingSignature,
+		},
+		{
+			name:      "malformed",
+			keys:      []string{trusted},
+			signature: []byte("not a signature"),
+			want:      ErrInvalidSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewVerifier(&telegraf.VerifyConfig{PublicKeys: tt.keys})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := v.Verify(data, tt.signature); err != tt.want {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signature")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	trusted, _ := generateKey(t)
+	keyFile := filepath.Join(dir, "telegraf.pub")
+	if err := ioutil.WriteFile(keyFile, []byte(trusted+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		config  telegraf.VerifyConfig
+		wantErr string
+	}{
+		{
+			name:   "key file",
+			config: telegraf.VerifyConfig{PublicKeyFiles: []string{keyFile}},
+		},
+		{
+			name:    "no keys",
+			wantErr: "no public keys",
+		},
+		{
+			name:    "missing key file",
+			config:  telegraf.VerifyConfig{PublicKeyFiles: []string{filepath.Join(dir, "missing.pub")}},
+			wantErr: "no such file",
+		},
+		{
+			name:    "invalid base64",
+			config:  telegraf.VerifyConfig{PublicKeys: []string{"!"}},
+			wantErr: "invalid public key",
+		},
+		{
+			name:    "wrong size",
+			config:  telegraf.VerifyConfig{PublicKeys: []string{"AAAA"}},
+			wantErr: "wrong size 3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewVerifier(&tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("NewVerifier() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewVerifier() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
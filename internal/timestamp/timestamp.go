@@ -0,0 +1,58 @@
+package timestamp
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Parse parses a timestamp value.  The format is one of "unix", "unix_ms",
+// "unix_us" or "unix_ns" for numeric timestamps, which may be given as a
+// number or a string, or else a time.Parse layout.  Layouts without a zone
+// are interpreted in the location, or UTC when nil.
+func Parse(format string, value interface{}, location *time.Location) (time.Time, error) {
+	var unit time.Duration
+	switch format {
+	case "unix":
+		unit = time.Second
+	case "unix_ms":
+		unit = time.Millisecond
+	case "unix_us":
+		unit = time.Microsecond
+	case "unix_ns":
+		unit = time.Nanosecond
+	default:
+		s, ok := value.(string)
+		if !ok {
+			return time.Time{}, fmt.Errorf("timestamp %v does not match format %q", value, format)
+		}
+		if location == nil {
+			location = time.UTC
+		}
+		return time.ParseInLocation(format, s, location)
+	}
+
+	var number float64
+	switch v := value.(type) {
+	case float64:
+		number = v
+	case int64:
+		return time.Unix(0, v*int64(unit)), nil
+	case string:
+		// Integers are parsed separately to avoid losing precision.
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Unix(0, i*int64(unit)), nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s timestamp: %q", format, v)
+		}
+		number = f
+	default:
+		return time.Time{}, fmt.Errorf("invalid %s timestamp: %v", format, value)
+	}
+
+	whole, frac := math.Modf(number)
+	return time.Unix(0, int64(whole)*int64(unit)+int64(frac*float64(unit))), nil
+}
@@ -0,0 +1,71 @@
+package timestamp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		value    interface{}
+		location *time.Location
+		want     time.Time
+		wantErr  string
+	}{
+		{name: "unix float", format: "unix", value: 1600000000.5, want: time.Unix(1600000000, 500000000)},
+		{name: "unix int", format: "unix", value: int64(1600000000), want: time.Unix(1600000000, 0)},
+		{name: "unix string", format: "unix", value: "1600000000", want: time.Unix(1600000000, 0)},
+		{name: "unix float string", format: "unix", value: "1600000000.25", want: time.Unix(1600000000, 250000000)},
+		{name: "unix_ms", format: "unix_ms", value: "1600000000123", want: time.Unix(1600000000, 123000000)},
+		{name: "unix_us", format: "unix_us", value: int64(1600000000123456), want: time.Unix(1600000000, 123456000)},
+		{name: "unix_ns", format: "unix_ns", value: "1600000000123456789", want: time.Unix(1600000000, 123456789)},
+		{
+			name:   "layout with zone",
+			format: time.RFC3339,
+			value:  "2020-01-02T03:04:05+01:00",
+			want:   time.Date(2020, 1, 2, 2, 4, 5, 0, time.UTC),
+		},
+		{
+			name:   "layout defaults to utc",
+			format: "2006-01-02 15:04:05",
+			value:  "2020-01-02 03:04:05",
+			want:   time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+		{
+			name:     "layout in location",
+			format:   "2006-01-02 15:04:05",
+			value:    "2020-01-02 03:04:05",
+			location: newYork,
+			want:     time.Date(2020, 1, 2, 8, 4, 5, 0, time.UTC),
+		},
+		{name: "invalid number", format: "unix", value: "soon", wantErr: `invalid unix timestamp: "soon"`},
+		{name: "invalid type", format: "unix_ms", value: true, wantErr: "invalid unix_ms timestamp: true"},
+		{name: "number with layout", format: time.RFC3339, value: 1.0, wantErr: "does not match format"},
+		{name: "layout mismatch", format: time.RFC3339, value: "yesterday", wantErr: "cannot parse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.format, tt.value, tt.location)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package telegraf
 
 import (
 	"context"
+	"io"
 )
 
 // Loader is the interface for a plugin that loads a Config.
@@ -15,6 +16,29 @@ type Loader interface {
 	Load(context.Context, ConfigRegistry) (*Config, error)
 }
 
+// VerifierLoader is a Loader that can verify the signature of its config
+// before it is parsed.
+type VerifierLoader interface {
+	// SetVerifier sets the Verifier for the Loader.  When set, configs
+	// without a valid signature must not be loaded.  Returns an error if
+	// the Loader is not configured to obtain signatures.
+	SetVerifier(verifier Verifier) error
+}
+
+// Verifier checks a detached signature over the raw bytes of a config.
+type Verifier interface {
+	Verify(data, signature []byte) error
+}
+
+// ConfigParser parses a config document in one format.
+type ConfigParser interface {
+	Parse(reader io.Reader) (*Config, error)
+}
+
+// ConfigParserFactory creates a ConfigParser that uses the ConfigRegistry
+// to create the config structs of the plugins.
+type ConfigParserFactory func(registry ConfigRegistry) ConfigParser
+
 // Should this be WatchWaiter?
 //
 // Waiter allows you to wait for a watch to complete.
@@ -1,5 +1,102 @@
 package telegraf
 
-// Metric is a standin for telegraf.Metric
+import (
+	"time"
+)
+
+// ValueType is an enumeration of metric types that represent a simple value.
+type ValueType int
+
+// Possible values for the ValueType enum.
+const (
+	_ ValueType = iota
+	Counter
+	Gauge
+	Untyped
+)
+
+// Tag represents a single tag key and value.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// Field represents a single field key and value.  The Value is always one
+// of int64, uint64, float64, string or bool.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Metric is a single measurement.
+//
+// Existing: metric.go Metric
 type Metric interface {
+	// Name is the measurement name of the metric.
+	Name() string
+
+	// Tags returns the tags as a map.  This method is deprecated, use
+	// TagList instead.
+	Tags() map[string]string
+
+	// TagList returns the tags sorted by key.  The list must not be
+	// modified.
+	TagList() []*Tag
+
+	// Fields returns the fields as a map.  This method is deprecated, use
+	// FieldList instead.
+	Fields() map[string]interface{}
+
+	// FieldList returns the fields.  The list must not be modified.
+	FieldList() []*Field
+
+	// Time is the timestamp of the metric.
+	Time() time.Time
+
+	// Type is the value type of the metric.
+	Type() ValueType
+
+	// SetName sets the measurement name.
+	SetName(name string)
+
+	// AddPrefix adds a string to the front of the measurement name.
+	AddPrefix(prefix string)
+
+	// AddSuffix adds a string to the end of the measurement name.
+	AddSuffix(suffix string)
+
+	// GetTag returns the value of a tag and if it exists.
+	GetTag(key string) (string, bool)
+
+	// HasTag reports if a tag exists.
+	HasTag(key string) bool
+
+	// AddTag adds a tag, replacing the value of an existing tag.
+	AddTag(key, value string)
+
+	// RemoveTag removes a tag if it exists.
+	RemoveTag(key string)
+
+	// GetField returns the value of a field and if it exists.
+	GetField(key string) (interface{}, bool)
+
+	// HasField reports if a field exists.
+	HasField(key string) bool
+
+	// AddField adds a field, replacing the value of an existing field.
+	// Values of an unsupported type are ignored.
+	AddField(key string, value interface{})
+
+	// RemoveField removes a field if it exists.
+	RemoveField(key string)
+
+	// SetTime sets the timestamp.
+	SetTime(t time.Time)
+
+	// Copy returns a deep copy of the metric.
+	Copy() Metric
+
+	// HashID returns an identifier for the series of the metric, metrics
+	// with the same name and tags have the same HashID.
+	HashID() uint64
 }
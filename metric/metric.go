@@ -0,0 +1,271 @@
+package metric
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+type metric struct {
+	name   string
+	tags   []*telegraf.Tag
+	fields []*telegraf.Field
+	tm     time.Time
+	tp     telegraf.ValueType
+}
+
+// New creates a Metric.  Field values are converted to one of the supported
+// field types; an error is returned for values that cannot be converted.
+// The optional ValueType defaults to telegraf.Untyped.
+func New(
+	name string,
+	tags map[string]string,
+	fields map[string]interface{},
+	tm time.Time,
+	tp ...telegraf.ValueType,
+) (telegraf.Metric, error) {
+	var vtype telegraf.ValueType
+	if len(tp) > 0 {
+		vtype = tp[0]
+	} else {
+		vtype = telegraf.Untyped
+	}
+
+	m := &metric{
+		name:   name,
+		tags:   make([]*telegraf.Tag, 0, len(tags)),
+		fields: make([]*telegraf.Field, 0, len(fields)),
+		tm:     tm,
+		tp:     vtype,
+	}
+
+	for k, v := range tags {
+		m.tags = append(m.tags, &telegraf.Tag{Key: k, Value: v})
+	}
+	sort.Slice(m.tags, func(i, j int) bool { return m.tags[i].Key < m.tags[j].Key })
+
+	for k, v := range fields {
+		value := convertField(v)
+		if value == nil {
+			return nil, fmt.Errorf("%s: unsupported type %T for field %s", name, v, k)
+		}
+		m.fields = append(m.fields, &telegraf.Field{Key: k, Value: value})
+	}
+	sort.Slice(m.fields, func(i, j int) bool { return m.fields[i].Key < m.fields[j].Key })
+
+	return m, nil
+}
+
+func (m *metric) String() string {
+	return fmt.Sprintf("%s %v %v %d", m.name, m.Tags(), m.Fields(), m.tm.UnixNano())
+}
+
+func (m *metric) Name() string {
+	return m.name
+}
+
+func (m *metric) Tags() map[string]string {
+	tags := make(map[string]string, len(m.tags))
+	for _, tag := range m.tags {
+		tags[tag.Key] = tag.Value
+	}
+	return tags
+}
+
+func (m *metric) TagList() []*telegraf.Tag {
+	return m.tags
+}
+
+func (m *metric) Fields() map[string]interface{} {
+	fields := make(map[string]interface{}, len(m.fields))
+	for _, field := range m.fields {
+		fields[field.Key] = field.Value
+	}
+	return fields
+}
+
+func (m *metric) FieldList() []*telegraf.Field {
+	return m.fields
+}
+
+func (m *metric) Time() time.Time {
+	return m.tm
+}
+
+func (m *metric) Type() telegraf.ValueType {
+	return m.tp
+}
+
+func (m *metric) SetName(name string) {
+	m.name = name
+}
+
+func (m *metric) AddPrefix(prefix string) {
+	m.name = prefix + m.name
+}
+
+func (m *metric) AddSuffix(suffix string) {
+	m.name = m.name + suffix
+}
+
+func (m *metric) GetTag(key string) (string, bool) {
+	for _, tag := range m.tags {
+		if tag.Key == key {
+			return tag.Value, true
+		}
+	}
+	return "", false
+}
+
+func (m *metric) HasTag(key string) bool {
+	_, ok := m.GetTag(key)
+	return ok
+}
+
+func (m *metric) AddTag(key, value string) {
+	for i, tag := range m.tags {
+		if key > tag.Key {
+			continue
+		}
+
+		if key == tag.Key {
+			tag.Value = value
+			return
+		}
+
+		m.tags = append(m.tags, nil)
+		copy(m.tags[i+1:], m.tags[i:])
+		m.tags[i] = &telegraf.Tag{Key: key, Value: value}
+		return
+	}
+
+	m.tags = append(m.tags, &telegraf.Tag{Key: key, Value: value})
+}
+
+func (m *metric) RemoveTag(key string) {
+	for i, tag := range m.tags {
+		if tag.Key == key {
+			copy(m.tags[i:], m.tags[i+1:])
+			m.tags[len(m.tags)-1] = nil
+			m.tags = m.tags[:len(m.tags)-1]
+			return
+		}
+	}
+}
+
+func (m *metric) GetField(key string) (interface{}, bool) {
+	for _, field := range m.fields {
+		if field.Key == key {
+			return field.Value, true
+		}
+	}
+	return nil, false
+}
+
+func (m *metric) HasField(key string) bool {
+	_, ok := m.GetField(key)
+	return ok
+}
+
+func (m *metric) AddField(key string, value interface{}) {
+	v := convertField(value)
+	if v == nil {
+		return
+	}
+
+	for _, field := range m.fields {
+		if field.Key == key {
+			field.Value = v
+			return
+		}
+	}
+	m.fields = append(m.fields, &telegraf.Field{Key: key, Value: v})
+}
+
+func (m *metric) RemoveField(key string) {
+	for i, field := range m.fields {
+		if field.Key == key {
+			copy(m.fields[i:], m.fields[i+1:])
+			m.fields[len(m.fields)-1] = nil
+			m.fields = m.fields[:len(m.fields)-1]
+			return
+		}
+	}
+}
+
+func (m *metric) SetTime(t time.Time) {
+	m.tm = t
+}
+
+func (m *metric) Copy() telegraf.Metric {
+	m2 := &metric{
+		name:   m.name,
+		tags:   make([]*telegraf.Tag, len(m.tags)),
+		fields: make([]*telegraf.Field, len(m.fields)),
+		tm:     m.tm,
+		tp:     m.tp,
+	}
+
+	for i, tag := range m.tags {
+		m2.tags[i] = &telegraf.Tag{Key: tag.Key, Value: tag.Value}
+	}
+	for i, field := range m.fields {
+		m2.fields[i] = &telegraf.Field{Key: field.Key, Value: field.Value}
+	}
+	return m2
+}
+
+func (m *metric) HashID() uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(m.name))
+	h.Write([]byte("\n"))
+	for _, tag := range m.tags {
+		h.Write([]byte(tag.Key))
+		h.Write([]byte("\n"))
+		h.Write([]byte(tag.Value))
+		h.Write([]byte("\n"))
+	}
+	return h.Sum64()
+}
+
+// convertField converts a value to a supported field type, returning nil if
+// the type is not supported.
+func convertField(v interface{}) interface{} {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case int64:
+		return v
+	case uint64:
+		return v
+	case string:
+		return v
+	case bool:
+		return v
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint:
+		return uint64(v)
+	case uint8:
+		return uint64(v)
+	case uint16:
+		return uint64(v)
+	case uint32:
+		return uint64(v)
+	case float32:
+		return float64(v)
+	case []byte:
+		return string(v)
+	default:
+		return nil
+	}
+}
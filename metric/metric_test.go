@@ -0,0 +1,176 @@
+package metric
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+func mustNew(t *testing.T) telegraf.Metric {
+	t.Helper()
+	m, err := New("cpu",
+		map[string]string{"host": "a", "cpu": "0"},
+		map[string]interface{}{"usage": 1.5, "idle": 90},
+		time.Unix(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestNew(t *testing.T) {
+	m := mustNew(t)
+	if m.Type() != telegraf.Untyped {
+		t.Errorf("type = %v, want untyped", m.Type())
+	}
+
+	var keys []string
+	for _, tag := range m.TagList() {
+		keys = append(keys, tag.Key)
+	}
+	for _, field := range m.FieldList() {
+		keys = append(keys, field.Key)
+	}
+	if want := []string{"cpu", "host", "idle", "usage"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys = %v, want sorted %v", keys, want)
+	}
+	if v, _ := m.GetField("idle"); v != int64(90) {
+		t.Errorf("idle = %#v, want int64(90)", v)
+	}
+
+	m, err := New("cpu", nil, map[string]interface{}{"value": 1.0}, time.Unix(0, 0), telegraf.Counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Type() != telegraf.Counter {
+		t.Errorf("type = %v, want counter", m.Type())
+	}
+
+	_, err = New("cpu", nil, map[string]interface{}{"bad": []int{1}}, time.Unix(0, 0))
+	if err == nil || !strings.Contains(err.Error(), "unsupported type []int for field bad") {
+		t.Errorf("New() error = %v, want unsupported type", err)
+	}
+}
+
+func TestConvertField(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  interface{}
+	}{
+		{int(1), int64(1)},
+		{int8(-2), int64(-2)},
+		{int16(3), int64(3)},
+		{int32(4), int64(4)},
+		{int64(5), int64(5)},
+		{uint(6), uint64(6)},
+		{uint8(7), uint64(7)},
+		{uint16(8), uint64(8)},
+		{uint32(9), uint64(9)},
+		{uint64(10), uint64(10)},
+		{float32(1.5), 1.5},
+		{2.5, 2.5},
+		{true, true},
+		{"s", "s"},
+		{[]byte("b"), "b"},
+		{struct{}{}, nil},
+	}
+	for _, tt := range tests {
+		if got := convertField(tt.value); got != tt.want {
+			t.Errorf("convertField(%#v) = %#v, want %#v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestTags(t *testing.T) {
+	m := mustNew(t)
+	m.AddTag("b", "1")
+	m.AddTag("z", "2")
+	m.AddTag("host", "b")
+	m.RemoveTag("cpu")
+	m.RemoveTag("missing")
+
+	var keys []string
+	for _, tag := range m.TagList() {
+		keys = append(keys, tag.Key)
+	}
+	if want := []string{"b", "host", "z"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("tag keys = %v, want %v", keys, want)
+	}
+	if v, ok := m.GetTag("host"); !ok || v != "b" {
+		t.Errorf("host = %q, %v, want %q", v, ok, "b")
+	}
+	if m.HasTag("cpu") {
+		t.Error("removed tag cpu is present")
+	}
+}
+
+func TestFields(t *testing.T) {
+	m := mustNew(t)
+	m.AddField("usage", 2)
+	m.AddField("new", "x")
+	m.AddField("bad", []int{1})
+	m.RemoveField("idle")
+
+	want := map[string]interface{}{"usage": int64(2), "new": "x"}
+	if !reflect.DeepEqual(m.Fields(), want) {
+		t.Errorf("fields = %v, want %v", m.Fields(), want)
+	}
+	if m.HasField("bad") {
+		t.Error("unsupported field bad was added")
+	}
+}
+
+func TestName(t *testing.T) {
+	m := mustNew(t)
+	m.AddPrefix("sys_")
+	m.AddSuffix("_total")
+	if m.Name() != "sys_cpu_total" {
+		t.Errorf("name = %q, want %q", m.Name(), "sys_cpu_total")
+	}
+	m.SetName("mem")
+	if m.Name() != "mem" {
+		t.Errorf("name = %q, want %q", m.Name(), "mem")
+	}
+}
+
+func TestCopy(t *testing.T) {
+	m := mustNew(t)
+	c := m.Copy()
+	c.AddTag("host", "b")
+	c.AddField("usage", 2.0)
+	c.SetName("copy")
+
+	if v, _ := m.GetTag("host"); v != "a" {
+		t.Errorf("original host = %q after modifying the copy", v)
+	}
+	if v, _ := m.GetField("usage"); v != 1.5 {
+		t.Errorf("original usage = %v after modifying the copy", v)
+	}
+	if m.Name() != "cpu" {
+		t.Errorf("original name = %q after modifying the copy", m.Name())
+	}
+}
+
+func TestHashID(t *testing.T) {
+	a := mustNew(t)
+	b := mustNew(t)
+	b.AddField("other", 1.0)
+	b.SetTime(time.Unix(100, 0))
+	if a.HashID() != b.HashID() {
+		t.Error("HashID differs for metrics of the same series")
+	}
+
+	b.AddTag("host", "b")
+	if a.HashID() == b.HashID() {
+		t.Error("HashID equal for metrics with different tags")
+	}
+
+	c := mustNew(t)
+	c.SetName("mem")
+	if a.HashID() == c.HashID() {
+		t.Error("HashID equal for metrics with different names")
+	}
+}
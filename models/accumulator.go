@@ -1,4 +1,106 @@
 package models
 
+import (
+	"fmt"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/metric"
+)
+
+// MetricMaker is a running plugin that adds metrics to an Accumulator.
+//
+// Existing: agent/accumulator.MetricMaker
+type MetricMaker interface {
+	// LogName identifies the plugin, such as "inputs.cpu".
+	LogName() string
+
+	// MakeMetric applies the plugin configuration to a metric, returning
+	// nil if the metric should be dropped.
+	MakeMetric(m telegraf.Metric) telegraf.Metric
+
+	// IncrErrors counts an error reported by the plugin.
+	IncrErrors()
+}
+
+// Accumulator is the Accumulator for a single RunningInput or
+// RunningAggregator; it applies the plugin's configuration to each metric
+// and sends it on to the outputs.
+//
+// Existing: agent/accumulator.accumulator
 type Accumulator struct {
+	maker   MetricMaker
+	metrics chan<- telegraf.Metric
+}
+
+// NewAccumulator creates an Accumulator that sends the metrics of the plugin
+// to the metrics channel.
+func NewAccumulator(maker MetricMaker, metrics chan<- telegraf.Metric) *Accumulator {
+	return &Accumulator{
+		maker:   maker,
+		metrics: metrics,
+	}
+}
+
+func (ac *Accumulator) AddFields(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	ac.addFields(measurement, fields, tags, telegraf.Untyped, t...)
+}
+
+func (ac *Accumulator) AddGauge(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	ac.addFields(measurement, fields, tags, telegraf.Gauge, t...)
+}
+
+func (ac *Accumulator) AddCounter(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	ac.addFields(measurement, fields, tags, telegraf.Counter, t...)
+}
+
+func (ac *Accumulator) addFields(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	tp telegraf.ValueType,
+	t ...time.Time,
+) {
+	tm := time.Now()
+	if len(t) > 0 {
+		tm = t[0]
+	}
+
+	m, err := metric.New(measurement, tags, fields, tm, tp)
+	if err != nil {
+		ac.AddError(err)
+		return
+	}
+	ac.AddMetric(m)
+}
+
+func (ac *Accumulator) AddMetric(m telegraf.Metric) {
+	if m := ac.maker.MakeMetric(m); m != nil {
+		ac.metrics <- m
+	}
+}
+
+// AddError logs the error along with the identity of the plugin and counts
+// it against the plugin.
+func (ac *Accumulator) AddError(err error) {
+	if err == nil {
+		return
+	}
+	ac.maker.IncrErrors()
+	fmt.Printf("E! [%s] %v\n", ac.maker.LogName(), err)
 }
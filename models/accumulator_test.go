@@ -0,0 +1,149 @@
+package models
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+// testMaker drops metrics named "drop" and tags the others.
+type testMaker struct {
+	errors int
+}
+
+func (*testMaker) LogName() string {
+	return "inputs.test"
+}
+
+func (*testMaker) MakeMetric(m telegraf.Metric) telegraf.Metric {
+	if m.Name() == "drop" {
+		return nil
+	}
+	m.AddTag("made", "true")
+	return m
+}
+
+func (m *testMaker) IncrErrors() {
+	m.errors++
+}
+
+func TestAccumulator(t *testing.T) {
+	tm := time.Unix(1600000000, 0)
+	tests := []struct {
+		name     string
+		add      func(acc telegraf.Accumulator)
+		wantType telegraf.ValueType
+		wantNone bool
+	}{
+		{
+			name: "fields",
+			add: func(acc telegraf.Accumulator) {
+				acc.AddFields("cpu", map[string]interface{}{"value": 1.0}, map[string]string{"host": "a"}, tm)
+			},
+			wantType: telegraf.Untyped,
+		},
+		{
+			name: "gauge",
+			add: func(acc telegraf.Accumulator) {
+				acc.AddGauge("cpu", map[string]interface{}{"value": 1.0}, map[string]string{"host": "a"}, tm)
+			},
+			wantType: telegraf.Gauge,
+		},
+		{
+			name: "counter",
+			add: func(acc telegraf.Accumulator) {
+				acc.AddCounter("cpu", map[string]interface{}{"value": 1.0}, map[string]string{"host": "a"}, tm)
+			},
+			wantType: telegraf.Counter,
+		},
+		{
+			name: "dropped by the maker",
+			add: func(acc telegraf.Accumulator) {
+				acc.AddFields("drop", map[string]interface{}{"value": 1.0}, nil, tm)
+			},
+			wantNone: true,
+		},
+		{
+			name: "invalid field",
+			add: func(acc telegraf.Accumulator) {
+				acc.AddFields("cpu", map[string]interface{}{"value": []int{1}}, nil, tm)
+			},
+			wantNone: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := make(chan telegraf.Metric, 1)
+			acc := NewAccumulator(&testMaker{}, metrics)
+			tt.add(acc)
+			close(metrics)
+
+			m, ok := <-metrics
+			if tt.wantNone {
+				if ok {
+					t.Fatalf("got metric %v, want none", m)
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("got no metric")
+			}
+			if m.Type() != tt.wantType {
+				t.Errorf("type = %v, want %v", m.Type(), tt.wantType)
+			}
+			if !m.Time().Equal(tm) {
+				t.Errorf("time = %v, want %v", m.Time(), tm)
+			}
+			wantTags := map[string]string{"host": "a", "made": "true"}
+			if !reflect.DeepEqual(m.Tags(), wantTags) {
+				t.Errorf("tags = %v, want %v", m.Tags(), wantTags)
+			}
+		})
+	}
+}
+
+func TestAccumulatorDefaultTime(t *testing.T) {
+	metrics := make(chan telegraf.Metric, 1)
+	acc := NewAccumulator(&testMaker{}, metrics)
+
+	before := time.Now()
+	acc.AddFields("cpu", map[string]interface{}{"value": 1.0}, nil)
+	m := <-metrics
+	if m.Time().Before(before) || m.Time().After(time.Now()) {
+		t.Errorf("time = %v, want the time of adding", m.Time())
+	}
+}
+
+func TestAccumulatorAddError(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	maker := &testMaker{}
+	acc := NewAccumulator(maker, make(chan telegraf.Metric))
+	acc.AddError(errors.New("failed"))
+	acc.AddError(nil)
+
+	os.Stdout = stdout
+	w.Close()
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(out) != "E! [inputs.test] failed\n" {
+		t.Errorf("logged %q, want %q", out, "E! [inputs.test] failed\n")
+	}
+	if maker.errors != 1 {
+		t.Errorf("errors = %d, want 1", maker.errors)
+	}
+}
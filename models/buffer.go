@@ -0,0 +1,101 @@
+package models
+
+import (
+	"sync"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+// Buffer is a bounded FIFO of metrics.  When the Buffer is full the oldest
+// metrics are dropped to make room.
+type Buffer struct {
+	mu    sync.Mutex
+	buf   []telegraf.Metric
+	first int
+	size  int
+}
+
+// NewBuffer creates a Buffer holding up to capacity metrics.
+func NewBuffer(capacity int) *Buffer {
+	return &Buffer{
+		buf: make([]telegraf.Metric, capacity),
+	}
+}
+
+// Len returns the number of metrics in the Buffer.
+func (b *Buffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size
+}
+
+// Cap returns the maximum number of metrics in the Buffer.
+func (b *Buffer) Cap() int {
+	return len(b.buf)
+}
+
+// Add adds metrics to the end of the Buffer, returning the number of metrics
+// that were dropped.
+func (b *Buffer) Add(metrics ...telegraf.Metric) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	dropped := 0
+	for _, m := range metrics {
+		if b.size == len(b.buf) {
+			b.buf[b.first] = nil
+			b.first = b.index(1)
+			b.size--
+			dropped++
+		}
+		b.buf[b.index(b.size)] = m
+		b.size++
+	}
+	return dropped
+}
+
+// Batch removes and returns up to n of the oldest metrics.
+func (b *Buffer) Batch(n int) []telegraf.Metric {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n > b.size {
+		n = b.size
+	}
+
+	batch := make([]telegraf.Metric, n)
+	for i := range batch {
+		batch[i] = b.buf[b.first]
+		b.buf[b.first] = nil
+		b.first = b.index(1)
+		b.size--
+	}
+	return batch
+}
+
+// Return places a batch that could not be written back at the front of the
+// Buffer so it is retried before newer metrics.  The oldest metrics of the
+// batch are dropped if there is not enough room, the number dropped is
+// returned.
+func (b *Buffer) Return(batch []telegraf.Metric) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	keep := len(b.buf) - b.size
+	if keep > len(batch) {
+		keep = len(batch)
+	}
+	dropped := len(batch) - keep
+
+	for i := len(batch) - 1; i >= dropped; i-- {
+		b.first = b.index(-1)
+		b.buf[b.first] = batch[i]
+		b.size++
+	}
+	return dropped
+}
+
+// index returns the position in buf that is offset from first.
+func (b *Buffer) index(offset int) int {
+	return ((b.first+offset)%len(b.buf) + len(b.buf)) % len(b.buf)
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/metric"
+)
+
+func testMetrics(t *testing.T, names ...string) []telegraf.Metric {
+	t.Helper()
+	metrics := make([]telegraf.Metric, 0, len(names))
+	for _, name := range names {
+		m, err := metric.New(name, nil, map[string]interface{}{"value": int64(1)}, time.Unix(0, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+func metricNames(metrics []telegraf.Metric) []string {
+	names := make([]string, 0, len(metrics))
+	for _, m := range metrics {
+		names = append(names, m.Name())
+	}
+	return names
+}
+
+func TestBuffer(t *testing.T) {
+	// Each step either adds the named metrics, takes a batch of size batch
+	// or returns the last batch taken.
+	type step struct {
+		add         []string
+		batch       int
+		ret         bool
+		wantBatch   []string
+		wantDropped int
+		wantLen     int
+	}
+	tests := []struct {
+		name     string
+		capacity int
+		steps    []step
+	}{
+		{
+			name:     "fifo",
+			capacity: 4,
+			steps: []step{
+				{add: []string{"a", "b", "c"}, wantLen: 3},
+				{batch: 2, wantBatch: []string{"a", "b"}, wantLen: 1},
+				{batch: 2, wantBatch: []string{"c"}, wantLen: 0},
+				{batch: 2, wantBatch: []string{}, wantLen: 0},
+			},
+		},
+		{
+			name:     "drop oldest when full",
+			capacity: 3,
+			steps: []step{
+				{add: []string{"a", "b", "c", "d", "e"}, wantDropped: 2, wantLen: 3},
+				{batch: 3, wantBatch: []string{"c", "d", "e"}, wantLen: 0},
+			},
+		},
+		{
+			name:     "wrap around",
+			capacity: 3,
+			steps: []step{
+				{add: []string{"a", "b"}, wantLen: 2},
+				{batch: 1, wantBatch: []string{"a"}, wantLen: 1},
+				{add: []string{"c", "d"}, wantLen: 3},
+				{batch: 3, wantBatch: []string{"b", "c", "d"}, wantLen: 0},
+			},
+		},
+		{
+			name:     "return before newer metrics",
+			capacity: 4,
+			steps: []step{
+				{add: []string{"a", "b"}, wantLen: 2},
+				{batch: 2, wantBatch: []string{"a", "b"}, wantLen: 0},
+				{add: []string{"c"}, wantLen: 1},
+				{ret: true, wantLen: 3},
+				{batch: 4, wantBatch: []string{"a", "b", "c"}, wantLen: 0},
+			},
+		},
+		{
+			name:     "return drops oldest of batch",
+			capacity: 3,
+			steps: []step{
+				{add: []string{"a", "b", "c"}, wantLen: 3},
+				{batch: 3, wantBatch: []string{"a", "b", "c"}, wantLen: 0},
+				{add: []string{"d", "e"}, wantLen: 2},
+				{ret: true, wantDropped: 2, wantLen: 3},
+				{batch: 3, wantBatch: []string{"c", "d", "e"}, wantLen: 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuffer(tt.capacity)
+			var last []telegraf.Metric
+			for i, s := range tt.steps {
+				t.Run(fmt.Sprint(i), func(t *testing.T) {
+					switch {
+					case s.add != nil:
+						if dropped := b.Add(testMetrics(t, s.add...)...); dropped != s.wantDropped {
+							t.Errorf("Add() dropped %d, want %d", dropped, s.wantDropped)
+						}
+					case s.ret:
+						if dropped := b.Return(last); dropped != s.wantDropped {
+							t.Errorf("Return() dropped %d, want %d", dropped, s.wantDropped)
+						}
+					default:
+						last = b.Batch(s.batch)
+						if got := metricNames(last); !reflect.DeepEqual(got, s.wantBatch) {
+							t.Errorf("Batch() = %v, want %v", got, s.wantBatch)
+						}
+					}
+					if b.Len() != s.wantLen {
+						t.Errorf("Len() = %d, want %d", b.Len(), s.wantLen)
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"sort"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/filter"
+)
+
+// Filter is a FilterConfig with its patterns compiled.
+//
+// Existing: internal/models/filter.Filter
+type Filter struct {
+	config *telegraf.FilterConfig
+
+	namePass   filter.Filter
+	nameDrop   filter.Filter
+	fieldPass  filter.Filter
+	fieldDrop  filter.Filter
+	tagPass    []tagFilter
+	tagDrop    []tagFilter
+	tagInclude filter.Filter
+	tagExclude filter.Filter
+}
+
+// tagFilter selects the values of a single tag.
+type tagFilter struct {
+	key    string
+	filter filter.Filter
+}
+
+// NewFilter compiles the patterns of the FilterConfig, returning an error if
+// any are invalid.
+func NewFilter(config *telegraf.FilterConfig) (*Filter, error) {
+	f := &Filter{config: config}
+
+	var err error
+	compile := func(patterns []string) filter.Filter {
+		if err != nil {
+			return nil
+		}
+		var compiled filter.Filter
+		compiled, err = filter.Compile(patterns)
+		return compiled
+	}
+
+	f.namePass = compile(config.NamePass)
+	f.nameDrop = compile(config.NameDrop)
+	f.fieldPass = compile(config.FieldPass)
+	f.fieldDrop = compile(config.FieldDrop)
+	f.tagInclude = compile(config.TagInclude)
+	f.tagExclude = compile(config.TagExclude)
+	f.tagPass = compileTags(config.TagPass, compile)
+	f.tagDrop = compileTags(config.TagDrop, compile)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func compileTags(
+	tags map[string][]string,
+	compile func([]string) filter.Filter,
+) []tagFilter {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	filters := make([]tagFilter, 0, len(keys))
+	for _, key := range keys {
+		filters = append(filters, tagFilter{key: key, filter: compile(tags[key])})
+	}
+	return filters
+}
+
+// Apply filters the metric and then applies the name modifiers and static
+// tags to it, so the filters match the metric as it was created.  Returns
+// nil if the metric is dropped.
+func (f *Filter) Apply(m telegraf.Metric) telegraf.Metric {
+	if !f.Select(m) {
+		return nil
+	}
+
+	f.Modify(m)
+	if len(m.FieldList()) == 0 {
+		return nil
+	}
+
+	f.ApplyModifiers(m)
+	return m
+}
+
+// ApplyModifiers applies the name modifiers and static tags to the metric.
+func (f *Filter) ApplyModifiers(m telegraf.Metric) {
+	if f.config.NameOverride != "" {
+		m.SetName(f.config.NameOverride)
+	}
+	if f.config.NamePrefix != "" {
+		m.AddPrefix(f.config.NamePrefix)
+	}
+	if f.config.NameSuffix != "" {
+		m.AddSuffix(f.config.NameSuffix)
+	}
+	for key, value := range f.config.Tags {
+		if !m.HasTag(key) {
+			m.AddTag(key, value)
+		}
+	}
+}
+
+// Select reports if the metric passes the name and tag filters.
+func (f *Filter) Select(m telegraf.Metric) bool {
+	if f.namePass != nil && !f.namePass.Match(m.Name()) {
+		return false
+	}
+	if f.nameDrop != nil && f.nameDrop.Match(m.Name()) {
+		return false
+	}
+	if len(f.tagPass) > 0 && !matchTags(f.tagPass, m) {
+		return false
+	}
+	if len(f.tagDrop) > 0 && matchTags(f.tagDrop, m) {
+		return false
+	}
+	return true
+}
+
+// Modify removes the fields and tags that do not pass the field and tag key
+// filters.
+func (f *Filter) Modify(m telegraf.Metric) {
+	var remove []string
+	for _, field := range m.FieldList() {
+		if !pass(f.fieldPass, f.fieldDrop, field.Key) {
+			remove = append(remove, field.Key)
+		}
+	}
+	for _, key := range remove {
+		m.RemoveField(key)
+	}
+
+	remove = remove[:0]
+	for _, tag := range m.TagList() {
+		if !pass(f.tagInclude, f.tagExclude, tag.Key) {
+			remove = append(remove, tag.Key)
+		}
+	}
+	for _, key := range remove {
+		m.RemoveTag(key)
+	}
+}
+
+// matchTags reports if any of the tag filters match the metric.
+func matchTags(filters []tagFilter, m telegraf.Metric) bool {
+	for _, tf := range filters {
+		if value, ok := m.GetTag(tf.key); ok && tf.filter != nil && tf.filter.Match(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func pass(include, exclude filter.Filter, key string) bool {
+	if include != nil && !include.Match(key) {
+		return false
+	}
+	if exclude != nil && exclude.Match(key) {
+		return false
+	}
+	return true
+}
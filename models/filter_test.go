@@ -0,0 +1,168 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/metric"
+)
+
+func TestFilterApply(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     telegraf.FilterConfig
+		wantName   string
+		wantTags   map[string]string
+		wantFields map[string]interface{}
+		dropped    bool
+	}{
+		{
+			name:       "no filter",
+			wantName:   "cpu",
+			wantTags:   map[string]string{"host": "a", "cpu": "cpu0"},
+			wantFields: map[string]interface{}{"user": 1.0, "system": 2.0},
+		},
+		{
+			name: "modifiers",
+			config: telegraf.FilterConfig{
+				NameOverride: "processor",
+				NamePrefix:   "sys_",
+				NameSuffix:   "_stats",
+				Tags:         map[string]string{"host": "b", "dc": "eu"},
+			},
+			wantName:   "sys_processor_stats",
+			wantTags:   map[string]string{"host": "a", "cpu": "cpu0", "dc": "eu"},
+			wantFields: map[string]interface{}{"user": 1.0, "system": 2.0},
+		},
+		{
+			name:    "namepass",
+			config:  telegraf.FilterConfig{SelectConfig: telegraf.SelectConfig{NamePass: []string{"mem"}}},
+			dropped: true,
+		},
+		{
+			name: "namepass before modifiers",
+			config: telegraf.FilterConfig{
+				NamePrefix:   "sys_",
+				SelectConfig: telegraf.SelectConfig{NamePass: []string{"sys_*"}},
+			},
+			dropped: true,
+		},
+		{
+			name: "namepass with name_override",
+			config: telegraf.FilterConfig{
+				NameOverride: "processor",
+				SelectConfig: telegraf.SelectConfig{NamePass: []string{"cpu"}},
+			},
+			wantName:   "processor",
+			wantTags:   map[string]string{"host": "a", "cpu": "cpu0"},
+			wantFields: map[string]interface{}{"user": 1.0, "system": 2.0},
+		},
+		{
+			name:    "namedrop",
+			config:  telegraf.FilterConfig{SelectConfig: telegraf.SelectConfig{NameDrop: []string{"c*"}}},
+			dropped: true,
+		},
+		{
+			name: "tagpass",
+			config: telegraf.FilterConfig{SelectConfig: telegraf.SelectConfig{
+				TagPass: map[string][]string{"cpu": {"cpu1"}, "host": {"a"}},
+			}},
+			wantName:   "cpu",
+			wantTags:   map[string]string{"host": "a", "cpu": "cpu0"},
+			wantFields: map[string]interface{}{"user": 1.0, "system": 2.0},
+		},
+		{
+			name: "tagpass no match",
+			config: telegraf.FilterConfig{SelectConfig: telegraf.SelectConfig{
+				TagPass: map[string][]string{"cpu": {"cpu1"}, "missing": {"*"}},
+			}},
+			dropped: true,
+		},
+		{
+			name: "tagdrop",
+			config: telegraf.FilterConfig{SelectConfig: telegraf.SelectConfig{
+				TagDrop: map[string][]string{"cpu": {"cpu[0-3]"}},
+			}},
+			dropped: true,
+		},
+		{
+			name:       "fieldpass",
+			config:     telegraf.FilterConfig{FieldPass: []string{"us*"}},
+			wantName:   "cpu",
+			wantTags:   map[string]string{"host": "a", "cpu": "cpu0"},
+			wantFields: map[string]interface{}{"user": 1.0},
+		},
+		{
+			name:       "fielddrop",
+			config:     telegraf.FilterConfig{FieldDrop: []string{"user"}},
+			wantName:   "cpu",
+			wantTags:   map[string]string{"host": "a", "cpu": "cpu0"},
+			wantFields: map[string]interface{}{"system": 2.0},
+		},
+		{
+			name:    "no fields left",
+			config:  telegraf.FilterConfig{FieldDrop: []string{"*"}},
+			dropped: true,
+		},
+		{
+			name:       "taginclude",
+			config:     telegraf.FilterConfig{TagInclude: []string{"host"}},
+			wantName:   "cpu",
+			wantTags:   map[string]string{"host": "a"},
+			wantFields: map[string]interface{}{"user": 1.0, "system": 2.0},
+		},
+		{
+			name:       "tagexclude",
+			config:     telegraf.FilterConfig{TagExclude: []string{"host"}},
+			wantName:   "cpu",
+			wantTags:   map[string]string{"cpu": "cpu0"},
+			wantFields: map[string]interface{}{"user": 1.0, "system": 2.0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFilter(&tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := metric.New("cpu",
+				map[string]string{"host": "a", "cpu": "cpu0"},
+				map[string]interface{}{"user": 1.0, "system": 2.0},
+				time.Unix(0, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out := f.Apply(m)
+			if tt.dropped {
+				if out != nil {
+					t.Fatalf("Apply() = %v, want dropped", out)
+				}
+				return
+			}
+			if out == nil {
+				t.Fatal("Apply() dropped the metric")
+			}
+			if out.Name() != tt.wantName {
+				t.Errorf("name = %q, want %q", out.Name(), tt.wantName)
+			}
+			if !reflect.DeepEqual(out.Tags(), tt.wantTags) {
+				t.Errorf("tags = %v, want %v", out.Tags(), tt.wantTags)
+			}
+			if !reflect.DeepEqual(out.Fields(), tt.wantFields) {
+				t.Errorf("fields = %v, want %v", out.Fields(), tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestNewFilterInvalidPattern(t *testing.T) {
+	config := &telegraf.FilterConfig{
+		SelectConfig: telegraf.SelectConfig{TagPass: map[string][]string{"host": {"["}}},
+	}
+	if _, err := NewFilter(config); err == nil {
+		t.Fatal("expected error for invalid pattern")
+	}
+}
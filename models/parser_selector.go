@@ -0,0 +1,126 @@
+package models
+
+import (
+	"fmt"
+	"mime"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/filter"
+)
+
+// ParserSelector chooses the Parser of an Input for the source of the data,
+// trying the named Parsers by their Order before falling back to the default.
+type ParserSelector struct {
+	parser  telegraf.Parser
+	parsers []*namedParser
+}
+
+// namedParser is a named Parser with the patterns of its ParserSelector
+// compiled.  Content types and file extensions are matched ignoring case.
+type namedParser struct {
+	name   string
+	parser telegraf.Parser
+
+	contentTypes   filter.Filter
+	topics         filter.Filter
+	fileExtensions filter.Filter
+}
+
+// NewParserSelector creates the default Parser and the named Parsers of an
+// Input.
+func NewParserSelector(
+	config *telegraf.InputConfig,
+	registry telegraf.Registry,
+) (*ParserSelector, error) {
+	parser, err := registry.CreateParser(config.Config.DataFormat, config.ParserConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parsers with the same Order are kept in the given order.
+	configs := make([]*telegraf.NamedParserConfig, len(config.Parsers))
+	copy(configs, config.Parsers)
+	sort.SliceStable(configs, func(i, j int) bool {
+		return configs[i].Config.Order < configs[j].Config.Order
+	})
+
+	s := &ParserSelector{parser: parser}
+	for _, c := range configs {
+		named, err := newNamedParser(c, registry)
+		if err != nil {
+			return nil, err
+		}
+		s.parsers = append(s.parsers, named)
+	}
+	return s, nil
+}
+
+func newNamedParser(
+	config *telegraf.NamedParserConfig,
+	registry telegraf.Registry,
+) (*namedParser, error) {
+	parser, err := registry.CreateParser(config.Config.DataFormat, config.ParserConfig)
+	if err != nil {
+		return nil, fmt.Errorf("parser %s: %v", config.Name, err)
+	}
+
+	p := &namedParser{name: config.Name, parser: parser}
+
+	selector := config.Config.ParserSelector
+	compile := func(patterns []string, lower bool) filter.Filter {
+		if err != nil {
+			return nil
+		}
+		if lower {
+			lowered := make([]string, len(patterns))
+			for i, pattern := range patterns {
+				lowered[i] = strings.ToLower(pattern)
+			}
+			patterns = lowered
+		}
+		var compiled filter.Filter
+		compiled, err = filter.Compile(patterns)
+		return compiled
+	}
+
+	p.contentTypes = compile(selector.ContentTypes, true)
+	p.topics = compile(selector.Topics, false)
+	p.fileExtensions = compile(selector.FileExtensions, true)
+	if err != nil {
+		return nil, fmt.Errorf("parser %s: %v", config.Name, err)
+	}
+	return p, nil
+}
+
+// Select returns the first named Parser matching the source, or the default
+// Parser if none match.  It is the ParserFunc given to ParserInputs.
+func (s *ParserSelector) Select(source telegraf.ParserSource) telegraf.Parser {
+	for _, p := range s.parsers {
+		if p.match(source) {
+			return p.parser
+		}
+	}
+	return s.parser
+}
+
+func (p *namedParser) match(source telegraf.ParserSource) bool {
+	if p.contentTypes != nil && source.ContentType != "" {
+		mediaType, _, err := mime.ParseMediaType(source.ContentType)
+		if err == nil && p.contentTypes.Match(mediaType) {
+			return true
+		}
+	}
+	if p.topics != nil && source.Topic != "" && p.topics.Match(source.Topic) {
+		return true
+	}
+	if p.fileExtensions != nil && source.Path != "" {
+		ext := strings.ToLower(filepath.Ext(source.Path))
+		if ext != "" && p.fileExtensions.Match(ext) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"testing"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+type testParserConfig struct {
+	ID string `toml:"id"`
+}
+
+type testParser struct {
+	id string
+}
+
+func newTestParser(config *testParserConfig) (telegraf.Parser, error) {
+	return &testParser{id: config.ID}, nil
+}
+
+func (p *testParser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	return nil, nil
+}
+
+func newTestParserRegistry(t *testing.T) telegraf.Registry {
+	t.Helper()
+	empty := map[string]telegraf.PluginFactory{}
+	registry, err := NewRegistry(
+		empty, empty, empty,
+		map[string]telegraf.PluginFactory{"test": newTestParser},
+		empty, empty, empty,
+		map[string]telegraf.ConfigParserFactory{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return registry
+}
+
+func namedTestParser(name string, order int, selector telegraf.ParserSelector) *telegraf.NamedParserConfig {
+	return &telegraf.NamedParserConfig{
+		Name: name,
+		Config: &telegraf.CommonNamedParserConfig{
+			ParserConfig:   telegraf.ParserConfig{DataFormat: "test"},
+			ParserSelector: selector,
+			Order:          order,
+		},
+		ParserConfig: &testParserConfig{ID: name},
+	}
+}
+
+func TestParserSelector(t *testing.T) {
+	config := &telegraf.InputConfig{
+		Config:       &telegraf.CommonInputConfig{ParserConfig: telegraf.ParserConfig{DataFormat: "test"}},
+		ParserConfig: &testParserConfig{ID: "default"},
+		Parsers: []*telegraf.NamedParserConfig{
+			namedTestParser("json", 0, telegraf.ParserSelector{
+				ContentTypes:   []string{"application/json"},
+				FileExtensions: []string{".json"},
+			}),
+			namedTestParser("sensors", 0, telegraf.ParserSelector{
+				Topics: []string{"sensors/*"},
+			}),
+			namedTestParser("alerts", 1, telegraf.ParserSelector{
+				Topics: []string{"sensors/alerts", "alerts"},
+			}),
+			namedTestParser("override", -1, telegraf.ParserSelector{
+				Topics: []string{"sensors/override"},
+			}),
+		},
+	}
+	selector, err := NewParserSelector(config, newTestParserRegistry(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		source telegraf.ParserSource
+		want   string
+	}{
+		{
+			name: "no source",
+			want: "default",
+		},
+		{
+			name:   "content type",
+			source: telegraf.ParserSource{ContentType: "application/json"},
+			want:   "json",
+		},
+		{
+			name:   "content type with parameters",
+			source: telegraf.ParserSource{ContentType: "Application/JSON; charset=utf-8"},
+			want:   "json",
+		},
+		{
+			name:   "invalid content type",
+			source: telegraf.ParserSource{ContentType: "application/json;="},
+			want:   "default",
+		},
+		{
+			name:   "file extension ignores case",
+			source: telegraf.ParserSource{Path: "/var/data/metrics.JSON"},
+			want:   "json",
+		},
+		{
+			name:   "no file extension",
+			source: telegraf.ParserSource{Path: "/var/data/json"},
+			want:   "default",
+		},
+		{
+			name:   "topic",
+			source: telegraf.ParserSource{Topic: "sensors/temperature"},
+			want:   "sensors",
+		},
+		{
+			name:   "same order tried by name",
+			source: telegraf.ParserSource{Topic: "alerts"},
+			want:   "alerts",
+		},
+		{
+			name:   "lower order first",
+			source: telegraf.ParserSource{Topic: "sensors/alerts"},
+			want:   "sensors",
+		},
+		{
+			name:   "negative order",
+			source: telegraf.ParserSource{Topic: "sensors/override"},
+			want:   "override",
+		},
+		{
+			name:   "no match",
+			source: telegraf.ParserSource{ContentType: "text/plain", Topic: "other"},
+			want:   "default",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := selector.Select(tt.source).(*testParser)
+			if parser.id != tt.want {
+				t.Errorf("Select() = %s, want %s", parser.id, tt.want)
+			}
+		})
+	}
+}
+
+func TestParserSelectorInvalidPattern(t *testing.T) {
+	config := &telegraf.InputConfig{
+		Config:       &telegraf.CommonInputConfig{ParserConfig: telegraf.ParserConfig{DataFormat: "test"}},
+		ParserConfig: &testParserConfig{},
+		Parsers: []*telegraf.NamedParserConfig{
+			namedTestParser("broken", 0, telegraf.ParserSelector{Topics: []string{"["}}),
+		},
+	}
+	if _, err := NewParserSelector(config, newTestParserRegistry(t)); err == nil {
+		t.Fatal("expected error for invalid pattern")
+	}
+}
@@ -12,6 +12,10 @@ func NewRegistry(
 	inputs map[string]telegraf.PluginFactory,
 	outputs map[string]telegraf.PluginFactory,
 	parsers map[string]telegraf.PluginFactory,
+	processors map[string]telegraf.PluginFactory,
+	aggregators map[string]telegraf.PluginFactory,
+	serializers map[string]telegraf.PluginFactory,
+	formats map[string]telegraf.ConfigParserFactory,
 ) (*registry, error) {
 	err := check(loaders)
 	if err != nil {
@@ -29,12 +33,28 @@ func NewRegistry(
 	if err != nil {
 		return nil, err
 	}
+	err = check(processors)
+	if err != nil {
+		return nil, err
+	}
+	err = check(aggregators)
+	if err != nil {
+		return nil, err
+	}
+	err = check(serializers)
+	if err != nil {
+		return nil, err
+	}
 
 	registry := &registry{
-		loaders: loaders,
-		inputs:  inputs,
-		outputs: outputs,
-		parsers: parsers,
+		loaders:     loaders,
+		inputs:      inputs,
+		outputs:     outputs,
+		parsers:     parsers,
+		processors:  processors,
+		aggregators: aggregators,
+		serializers: serializers,
+		formats:     formats,
 	}
 
 	return registry, nil
@@ -56,6 +76,12 @@ func (c *registry) GetFactory(
 		factory, ok = c.outputs[name]
 	case telegraf.ParserType:
 		factory, ok = c.parsers[name]
+	case telegraf.ProcessorType:
+		factory, ok = c.processors[name]
+	case telegraf.AggregatorType:
+		factory, ok = c.aggregators[name]
+	case telegraf.SerializerType:
+		factory, ok = c.serializers[name]
 	}
 
 	return factory, ok
@@ -84,6 +110,15 @@ func (c *configs) GetPluginConfig(
 	return reflect.New(configType.Elem()).Interface(), true
 }
 
+// GetConfigParser returns the ConfigParser of a config format.
+func (c *configs) GetConfigParser(format string) (telegraf.ConfigParser, bool) {
+	factory, ok := c.registry.formats[format]
+	if !ok {
+		return nil, false
+	}
+	return factory(c), true
+}
+
 func (c *registry) CreateInputs(
 	name string,
 	config telegraf.PluginConfig,
@@ -110,6 +145,19 @@ func (c *registry) CreateParser(
 	return parser, nil
 }
 
+func (c *registry) CreateSerializer(
+	name string,
+	config telegraf.PluginConfig,
+) (telegraf.Serializer, error) {
+	plugins, err := c.createPlugins(telegraf.SerializerType, name, config)
+	if err != nil {
+		return nil, err
+	}
+
+	serializer := plugins.(telegraf.Serializer)
+	return serializer, nil
+}
+
 func (c *registry) CreateOutputs(
 	name string,
 	config telegraf.PluginConfig,
@@ -123,6 +171,32 @@ func (c *registry) CreateOutputs(
 	return outputs, nil
 }
 
+func (c *registry) CreateProcessors(
+	name string,
+	config telegraf.PluginConfig,
+) ([]telegraf.Processor, error) {
+	plugins, err := c.createPlugins(telegraf.ProcessorType, name, config)
+	if err != nil {
+		return nil, err
+	}
+
+	processors := plugins.([]telegraf.Processor)
+	return processors, nil
+}
+
+func (c *registry) CreateAggregators(
+	name string,
+	config telegraf.PluginConfig,
+) ([]telegraf.Aggregator, error) {
+	plugins, err := c.createPlugins(telegraf.AggregatorType, name, config)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregators := plugins.([]telegraf.Aggregator)
+	return aggregators, nil
+}
+
 func (c *registry) CreateLoaders(
 	name string,
 	config telegraf.PluginConfig,
@@ -137,10 +211,14 @@ func (c *registry) CreateLoaders(
 }
 
 type registry struct {
-	loaders map[string]telegraf.PluginFactory
-	inputs  map[string]telegraf.PluginFactory
-	outputs map[string]telegraf.PluginFactory
-	parsers map[string]telegraf.PluginFactory
+	loaders     map[string]telegraf.PluginFactory
+	inputs      map[string]telegraf.PluginFactory
+	outputs     map[string]telegraf.PluginFactory
+	parsers     map[string]telegraf.PluginFactory
+	processors  map[string]telegraf.PluginFactory
+	aggregators map[string]telegraf.PluginFactory
+	serializers map[string]telegraf.PluginFactory
+	formats     map[string]telegraf.ConfigParserFactory
 }
 
 // configs provides access to plugins config structure by type and name.
@@ -165,6 +243,12 @@ func (c *registry) createPlugins(
 		factory, ok = c.outputs[name]
 	case telegraf.ParserType:
 		factory, ok = c.parsers[name]
+	case telegraf.ProcessorType:
+		factory, ok = c.processors[name]
+	case telegraf.AggregatorType:
+		factory, ok = c.aggregators[name]
+	case telegraf.SerializerType:
+		factory, ok = c.serializers[name]
 	}
 	if !ok {
 		return nil, fmt.Errorf("unknown plugin %s", name)
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+const (
+	defaultAggregatorPeriod = 30 * time.Second
+	defaultAggregatorDelay  = 100 * time.Millisecond
+)
+
+// RunningAggregator adds the metrics selected by the filter to the
+// Aggregator and tracks the period that the aggregates are for.
+//
+// Existing: internal/models/running_aggregator.RunningAggregator
+type RunningAggregator struct {
+	Config     *telegraf.CommonAggregatorConfig
+	Aggregator telegraf.Aggregator
+	Name       string
+
+	filter *Filter
+	errors uint64
+
+	mu          sync.Mutex
+	periodStart time.Time
+	periodEnd   time.Time
+}
+
+func NewRunningAggregators(
+	name string,
+	config *telegraf.AggregatorConfig,
+	registry telegraf.Registry,
+) ([]*RunningAggregator, error) {
+	if config.Config.Period.Duration <= 0 {
+		config.Config.Period.Duration = defaultAggregatorPeriod
+	}
+	if config.Config.Delay.Duration <= 0 {
+		config.Config.Delay.Duration = defaultAggregatorDelay
+	}
+
+	filter, err := NewFilter(&config.Config.FilterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("aggregators.%s: %v", name, err)
+	}
+
+	aggregators, err := registry.CreateAggregators(name, config.PluginConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	r := make([]*RunningAggregator, len(aggregators))
+	for i, aggregator := range aggregators {
+		r[i] = &RunningAggregator{
+			Config:     config.Config,
+			Aggregator: aggregator,
+			Name:       name,
+			filter:     filter,
+		}
+	}
+	return r, nil
+}
+
+// LogName returns the name of the aggregator for logging.
+func (r *RunningAggregator) LogName() string {
+	return "aggregators." + r.Name
+}
+
+// IncrErrors counts an error reported by the aggregator.
+func (r *RunningAggregator) IncrErrors() {
+	atomic.AddUint64(&r.errors, 1)
+}
+
+// Errors returns the number of errors reported by the aggregator.
+func (r *RunningAggregator) Errors() uint64 {
+	return atomic.LoadUint64(&r.errors)
+}
+
+// MakeMetric applies the name modifiers and static tags to an aggregate.
+func (r *RunningAggregator) MakeMetric(m telegraf.Metric) telegraf.Metric {
+	r.filter.ApplyModifiers(m)
+	return m
+}
+
+// UpdateWindow starts the current period at start.
+func (r *RunningAggregator) UpdateWindow(start time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.periodStart = start
+	r.periodEnd = start.Add(r.Config.Period.Duration)
+}
+
+// EndPeriod returns the end of the current period.
+func (r *RunningAggregator) EndPeriod() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.periodEnd
+}
+
+// Add adds a copy of the metric to the Aggregator if it is selected by the
+// filter and its timestamp falls within the current period, allowing for
+// the grace and delay.  Reports if the original metric should be dropped.
+func (r *RunningAggregator) Add(m telegraf.Metric) bool {
+	if !r.filter.Select(m) {
+		return false
+	}
+
+	m = m.Copy()
+	r.filter.Modify(m)
+	if len(m.FieldList()) == 0 {
+		return r.Config.DropOriginal
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m.Time().Before(r.periodStart.Add(-r.Config.Grace.Duration)) ||
+		m.Time().After(r.periodEnd.Add(r.Config.Delay.Duration)) {
+		return r.Config.DropOriginal
+	}
+
+	r.Aggregator.Add(m)
+	return r.Config.DropOriginal
+}
+
+// Push adds the aggregates of the current period to the Accumulator, then
+// resets the Aggregator and moves on to the next period.
+func (r *RunningAggregator) Push(acc telegraf.Accumulator) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.periodStart = r.periodEnd
+	r.periodEnd = r.periodStart.Add(r.Config.Period.Duration)
+
+	r.Aggregator.Push(acc)
+	r.Aggregator.Reset()
+}
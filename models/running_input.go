@@ -1,6 +1,10 @@
 package models
 
 import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
 	telegraf "github.com/influxdata/tgconfig"
 )
 
@@ -11,6 +15,11 @@ import (
 type RunningInput struct {
 	Config *telegraf.CommonInputConfig
 	Input  telegraf.Input
+	Name   string
+
+	filter *Filter
+
+	errors uint64
 }
 
 func NewRunningInputs(
@@ -18,6 +27,15 @@ func NewRunningInputs(
 	config *telegraf.InputConfig,
 	registry telegraf.Registry,
 ) ([]*RunningInput, error) {
+	if config.Config.Interval.Duration < 0 {
+		return nil, fmt.Errorf("inputs.%s: interval must not be negative", name)
+	}
+
+	filter, err := NewFilter(&config.Config.FilterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("inputs.%s: %v", name, err)
+	}
+
 	inputs, err := registry.CreateInputs(name, config.PluginConfig)
 	if err != nil {
 		return nil, err
@@ -26,21 +44,84 @@ func NewRunningInputs(
 	for _, input := range inputs {
 		switch input := input.(type) {
 		case telegraf.ParserInput:
-			parserName := "influx"
-			if config.Config.DataFormat != "" {
-				parserName = config.Config.DataFormat
+			config, err := withDataFormat(config, input, registry)
+			if err != nil {
+				return nil, fmt.Errorf("inputs.%s: %v", name, err)
 			}
-			parser, err := registry.CreateParser(parserName, config.ParserConfig)
+
+			// Each Input has its own Parsers as they may not be safe for
+			// concurrent use.
+			selector, err := NewParserSelector(config, registry)
 			if err != nil {
-				return nil, err
+				return nil, fmt.Errorf("inputs.%s: %v", name, err)
+			}
+			input.SetParserFunc(selector.Select)
+		default:
+			if len(config.ParserOptions) > 0 {
+				return nil, fmt.Errorf("inputs.%s: input does not use a parser, invalid options: %s",
+					name, strings.Join(config.ParserOptions, ", "))
 			}
-			input.SetParser(parser)
 		}
 	}
 
 	r := make([]*RunningInput, len(inputs))
 	for i, input := range inputs {
-		r[i] = &RunningInput{config.Config, input}
+		r[i] = &RunningInput{
+			Config: config.Config,
+			Input:  input,
+			Name:   name,
+			filter: filter,
+		}
 	}
 	return r, nil
 }
+
+// withDataFormat returns the config with the data format chosen by the
+// Input when data_format is not set.
+func withDataFormat(
+	config *telegraf.InputConfig,
+	input telegraf.ParserInput,
+	registry telegraf.Registry,
+) (*telegraf.InputConfig, error) {
+	if config.Config.DataFormat != "" {
+		return config, nil
+	}
+
+	chooser, ok := input.(telegraf.DataFormatInput)
+	if !ok {
+		return nil, fmt.Errorf("data_format is required")
+	}
+	dataFormat := chooser.DataFormat()
+	parserConfig, ok := registry.GetConfigRegistry().GetPluginConfig(telegraf.ParserType, dataFormat)
+	if !ok {
+		return nil, fmt.Errorf("unknown data format: %s", dataFormat)
+	}
+
+	common := *config.Config
+	common.DataFormat = dataFormat
+	c := *config
+	c.Config = &common
+	c.ParserConfig = parserConfig
+	return &c, nil
+}
+
+// LogName returns the name of the input for logging.
+func (r *RunningInput) LogName() string {
+	return "inputs." + r.Name
+}
+
+// MakeMetric applies the input configuration to a metric, returning nil if
+// the metric should be dropped.
+func (r *RunningInput) MakeMetric(m telegraf.Metric) telegraf.Metric {
+	return r.filter.Apply(m)
+}
+
+// IncrErrors counts an error reported by the input.
+func (r *RunningInput) IncrErrors() {
+	atomic.AddUint64(&r.errors, 1)
+}
+
+// Errors returns the number of errors reported by the input.
+func (r *RunningInput) Errors() uint64 {
+	return atomic.LoadUint64(&r.errors)
+}
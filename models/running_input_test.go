@@ -0,0 +1,126 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+// testInputConfig creates a testDataFormatInput choosing DataFormat, or a
+// testParserInput if it is not set.
+type testInputConfig struct {
+	DataFormat string
+}
+
+type testParserInput struct {
+	dataFormat string
+	parserFunc telegraf.ParserFunc
+}
+
+func (p *testParserInput) Gather(acc telegraf.Accumulator) error {
+	return nil
+}
+
+func (p *testParserInput) SetParserFunc(fn telegraf.ParserFunc) {
+	p.parserFunc = fn
+}
+
+type testDataFormatInput struct {
+	testParserInput
+}
+
+func (p *testDataFormatInput) DataFormat() string {
+	return p.dataFormat
+}
+
+func newTestParserInput(config *testInputConfig) ([]telegraf.Input, error) {
+	if config.DataFormat == "" {
+		return []telegraf.Input{&testParserInput{}}, nil
+	}
+	return []telegraf.Input{&testDataFormatInput{testParserInput{dataFormat: config.DataFormat}}}, nil
+}
+
+func TestNewRunningInputsDataFormat(t *testing.T) {
+	empty := map[string]telegraf.PluginFactory{}
+	registry, err := NewRegistry(
+		empty,
+		map[string]telegraf.PluginFactory{"test": newTestParserInput},
+		empty,
+		map[string]telegraf.PluginFactory{"test": newTestParser},
+		empty, empty, empty,
+		map[string]telegraf.ConfigParserFactory{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		config     *telegraf.InputConfig
+		wantParser string
+		wantErr    string
+	}{
+		{
+			name: "configured",
+			config: &telegraf.InputConfig{
+				Config:       &telegraf.CommonInputConfig{ParserConfig: telegraf.ParserConfig{DataFormat: "test"}},
+				PluginConfig: &testInputConfig{DataFormat: "missing"},
+				ParserConfig: &testParserConfig{ID: "configured"},
+			},
+			wantParser: "configured",
+		},
+		{
+			name: "chosen by input",
+			config: &telegraf.InputConfig{
+				Config:       &telegraf.CommonInputConfig{},
+				PluginConfig: &testInputConfig{DataFormat: "test"},
+			},
+		},
+		{
+			name: "unknown data format chosen by input",
+			config: &telegraf.InputConfig{
+				Config:       &telegraf.CommonInputConfig{},
+				PluginConfig: &testInputConfig{DataFormat: "missing"},
+			},
+			wantErr: "inputs.test: unknown data format: missing",
+		},
+		{
+			name: "required",
+			config: &telegraf.InputConfig{
+				Config:       &telegraf.CommonInputConfig{},
+				PluginConfig: &testInputConfig{},
+			},
+			wantErr: "inputs.test: data_format is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputs, err := NewRunningInputs("test", tt.config, registry)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewRunningInputs() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var input *testParserInput
+			switch in := inputs[0].Input.(type) {
+			case *testParserInput:
+				input = in
+			case *testDataFormatInput:
+				input = &in.testParserInput
+			}
+			parser := input.parserFunc(telegraf.ParserSource{}).(*testParser)
+			if parser.id != tt.wantParser {
+				t.Errorf("parser = %q, want %q", parser.id, tt.wantParser)
+			}
+			if tt.config.Config.DataFormat == "" && tt.config.ParserConfig != nil {
+				t.Errorf("config was modified")
+			}
+		})
+	}
+}
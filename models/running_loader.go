@@ -2,8 +2,13 @@ package models
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"path"
 
 	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/internal/signature"
 )
 
 // RunningLoader exists for symmetry with the other Running classes.
@@ -11,6 +16,9 @@ type RunningLoader struct {
 	Config *telegraf.CommonLoaderConfig
 	Loader telegraf.Loader
 	Name   string
+	// ID identifies the Loader by its name and a hash of its plugin
+	// configuration, two Loaders with the same ID load the same config.
+	ID string
 }
 
 func NewRunningLoaders(
@@ -18,17 +26,73 @@ func NewRunningLoaders(
 	config *telegraf.LoaderConfig,
 	registry telegraf.Registry,
 ) ([]*RunningLoader, error) {
+	switch config.Config.Conflict {
+	case "":
+		config.Config.Conflict = telegraf.ConflictAppend
+	case telegraf.ConflictAppend, telegraf.ConflictReplace, telegraf.ConflictError:
+	default:
+		return nil, fmt.Errorf("unknown conflict policy for loader %s: %s",
+			name, config.Config.Conflict)
+	}
+
+	if policy := config.Config.Policy; policy != nil {
+		for _, patterns := range [][]string{
+			policy.Inputs, policy.Outputs, policy.Processors, policy.Aggregators,
+			policy.Loaders,
+		} {
+			for _, pattern := range patterns {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return nil, fmt.Errorf("invalid policy pattern for loader %s: %q",
+						name, pattern)
+				}
+			}
+		}
+	}
+
 	loaders, err := registry.CreateLoaders(name, config.PluginConfig)
 	if err != nil {
 		return nil, err
 	}
 
+	if config.Config.Verify != nil {
+		verifier, err := signature.NewVerifier(config.Config.Verify)
+		if err != nil {
+			return nil, fmt.Errorf("loader %s: %v", name, err)
+		}
+
+		for _, loader := range loaders {
+			switch loader := loader.(type) {
+			case telegraf.VerifierLoader:
+				if err := loader.SetVerifier(verifier); err != nil {
+					return nil, fmt.Errorf("loader %s: %v", name, err)
+				}
+			default:
+				return nil, fmt.Errorf(
+					"loader %s does not support signature verification", name)
+			}
+		}
+	}
+
+	pluginConfig, err := json.Marshal(config.PluginConfig)
+	if err != nil {
+		return nil, err
+	}
+	// Only a hash of the plugin configuration is used, as it may contain
+	// secrets and the ID is logged.
+	sum := sha256.Sum256(pluginConfig)
+
 	r := make([]*RunningLoader, len(loaders))
 	for i, loader := range loaders {
+		id := fmt.Sprintf("%s:%x", name, sum[:6])
+		if len(loaders) > 1 {
+			id = fmt.Sprintf("%s#%d", id, i)
+		}
+
 		r[i] = &RunningLoader{
 			Config: config.Config,
 			Loader: loader,
 			Name:   name,
+			ID:     id,
 		}
 	}
 	return r, nil
@@ -42,5 +106,68 @@ func (rc *RunningLoader) Load(
 	ctx context.Context,
 	registry telegraf.ConfigRegistry,
 ) (*telegraf.Config, error) {
-	return rc.Loader.Load(ctx, registry)
+	conf, err := rc.Loader.Load(ctx, registry)
+	if err != nil {
+		return nil, err
+	}
+
+	err = rc.CheckPolicy(conf)
+	if err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// CheckPolicy returns an error if the config contains anything not permitted
+// by the LoaderPolicy.
+func (rc *RunningLoader) CheckPolicy(conf *telegraf.Config) error {
+	policy := rc.Config.Policy
+	if policy == nil {
+		return nil
+	}
+
+	if conf.Agent != nil && !policy.Agent {
+		return fmt.Errorf("loader %s: agent settings are not permitted by policy",
+			rc.Name)
+	}
+	for name := range conf.Inputs {
+		if !permitted(policy.Inputs, name) {
+			return fmt.Errorf("loader %s: input %s is not permitted by policy",
+				rc.Name, name)
+		}
+	}
+	for name := range conf.Outputs {
+		if !permitted(policy.Outputs, name) {
+			return fmt.Errorf("loader %s: output %s is not permitted by policy",
+				rc.Name, name)
+		}
+	}
+	for name := range conf.Processors {
+		if !permitted(policy.Processors, name) {
+			return fmt.Errorf("loader %s: processor %s is not permitted by policy",
+				rc.Name, name)
+		}
+	}
+	for name := range conf.Aggregators {
+		if !permitted(policy.Aggregators, name) {
+			return fmt.Errorf("loader %s: aggregator %s is not permitted by policy",
+				rc.Name, name)
+		}
+	}
+	for name := range conf.Loaders {
+		if !permitted(policy.Loaders, name) {
+			return fmt.Errorf("loader %s: loader %s is not permitted by policy",
+				rc.Name, name)
+		}
+	}
+	return nil
+}
+
+func permitted(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,219 @@
+package models
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+type testLoaderConfig struct {
+	Command     string   `toml:"command"`
+	Environment []string `toml:"environment"`
+}
+
+type testLoader struct{}
+
+func newTestLoaders(config *testLoaderConfig) ([]telegraf.Loader, error) {
+	return []telegraf.Loader{&testLoader{}}, nil
+}
+
+func (l *testLoader) Load(ctx context.Context, registry telegraf.ConfigRegistry) (*telegraf.Config, error) {
+	return &telegraf.Config{}, nil
+}
+
+func (l *testLoader) Watch(ctx context.Context) (telegraf.Waiter, error) {
+	return nil, nil
+}
+
+func newTestLoaderRegistry(t *testing.T) telegraf.Registry {
+	t.Helper()
+	empty := map[string]telegraf.PluginFactory{}
+	registry, err := NewRegistry(
+		map[string]telegraf.PluginFactory{"test": newTestLoaders},
+		empty, empty, empty, empty, empty, empty,
+		map[string]telegraf.ConfigParserFactory{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return registry
+}
+
+func newTestRunningLoader(t *testing.T, common *telegraf.CommonLoaderConfig, config *testLoaderConfig) (*RunningLoader, error) {
+	t.Helper()
+	loaders, err := NewRunningLoaders("test", &telegraf.LoaderConfig{
+		Config:       common,
+		PluginConfig: config,
+	}, newTestLoaderRegistry(t))
+	if err != nil {
+		return nil, err
+	}
+	return loaders[0], nil
+}
+
+func TestRunningLoaderID(t *testing.T) {
+	config := &testLoaderConfig{
+		Command:     "/usr/bin/config",
+		Environment: []string{"TOKEN=s3cr3t"},
+	}
+
+	loader, err := newTestRunningLoader(t, &telegraf.CommonLoaderConfig{}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(loader.ID, "test:") {
+		t.Errorf("ID %q does not start with the loader name", loader.ID)
+	}
+	for _, secret := range []string{"s3cr3t", "TOKEN", "/usr/bin/config"} {
+		if strings.Contains(loader.ID, secret) {
+			t.Errorf("ID %q contains the config value %q", loader.ID, secret)
+		}
+	}
+
+	same, err := newTestRunningLoader(t, &telegraf.CommonLoaderConfig{}, &testLoaderConfig{
+		Command:     "/usr/bin/config",
+		Environment: []string{"TOKEN=s3cr3t"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same.ID != loader.ID {
+		t.Errorf("got ID %q for the same config, want %q", same.ID, loader.ID)
+	}
+
+	other, err := newTestRunningLoader(t, &telegraf.CommonLoaderConfig{}, &testLoaderConfig{
+		Command:     "/usr/bin/config",
+		Environment: []string{"TOKEN=other"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.ID == loader.ID {
+		t.Errorf("got the same ID %q for a different config", other.ID)
+	}
+}
+
+func TestNewRunningLoadersErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		common  *telegraf.CommonLoaderConfig
+		wantErr string
+	}{
+		{
+			name:    "unknown conflict policy",
+			common:  &telegraf.CommonLoaderConfig{Conflict: "merge"},
+			wantErr: "unknown conflict policy for loader test: merge",
+		},
+		{
+			name: "invalid policy pattern",
+			common: &telegraf.CommonLoaderConfig{
+				Policy: &telegraf.LoaderPolicy{Inputs: []string{"["}},
+			},
+			wantErr: "invalid policy pattern for loader test",
+		},
+		{
+			name: "verify unsupported",
+			common: &telegraf.CommonLoaderConfig{
+				Verify: &telegraf.VerifyConfig{
+					PublicKeys: []string{base64.StdEncoding.EncodeToString(make([]byte, ed25519.PublicKeySize))},
+				},
+			},
+			wantErr: "loader test does not support signature verification",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestRunningLoader(t, tt.common, &testLoaderConfig{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewRunningLoaders() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckPolicy(t *testing.T) {
+	policy := &telegraf.LoaderPolicy{
+		Inputs:  []string{"cpu", "disk*"},
+		Outputs: []string{"*"},
+	}
+	tests := []struct {
+		name    string
+		policy  *telegraf.LoaderPolicy
+		config  *telegraf.Config
+		wantErr string
+	}{
+		{
+			name: "no policy",
+			config: &telegraf.Config{
+				Agent:  &telegraf.AgentConfig{},
+				Inputs: map[string][]*telegraf.InputConfig{"mem": nil},
+			},
+		},
+		{
+			name:   "permitted",
+			policy: policy,
+			config: &telegraf.Config{
+				Inputs:  map[string][]*telegraf.InputConfig{"cpu": nil, "diskio": nil},
+				Outputs: map[string][]*telegraf.OutputConfig{"file": nil},
+			},
+		},
+		{
+			name:    "input",
+			policy:  policy,
+			config:  &telegraf.Config{Inputs: map[string][]*telegraf.InputConfig{"mem": nil}},
+			wantErr: "input mem is not permitted",
+		},
+		{
+			name:    "processor",
+			policy:  policy,
+			config:  &telegraf.Config{Processors: map[string][]*telegraf.ProcessorConfig{"rename": nil}},
+			wantErr: "processor rename is not permitted",
+		},
+		{
+			name:    "aggregator",
+			policy:  policy,
+			config:  &telegraf.Config{Aggregators: map[string][]*telegraf.AggregatorConfig{"minmax": nil}},
+			wantErr: "aggregator minmax is not permitted",
+		},
+		{
+			name:    "loader",
+			policy:  policy,
+			config:  &telegraf.Config{Loaders: map[string][]*telegraf.LoaderConfig{"exec": nil}},
+			wantErr: "loader exec is not permitted",
+		},
+		{
+			name:    "agent",
+			policy:  policy,
+			config:  &telegraf.Config{Agent: &telegraf.AgentConfig{}},
+			wantErr: "agent settings are not permitted",
+		},
+		{
+			name:   "agent permitted",
+			policy: &telegraf.LoaderPolicy{Agent: true},
+			config: &telegraf.Config{Agent: &telegraf.AgentConfig{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, err := newTestRunningLoader(t,
+				&telegraf.CommonLoaderConfig{Policy: tt.policy}, &testLoaderConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = loader.CheckPolicy(tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CheckPolicy() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("CheckPolicy() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
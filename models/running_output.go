@@ -1,9 +1,22 @@
 package models
 
 import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
 	telegraf "github.com/influxdata/tgconfig"
 )
 
+const (
+	// DefaultMetricBatchSize is used when metric_batch_size is not set.
+	DefaultMetricBatchSize = 1000
+
+	// DefaultMetricBufferLimit is used when metric_buffer_limit is not set.
+	DefaultMetricBufferLimit = 10000
+)
+
 // RunningOutput ensures measurement filtering is applied correctly to all
 // Output, handles buffering, and ensures the Output is used correctly with
 // respect to concurrency.
@@ -12,6 +25,27 @@ import (
 type RunningOutput struct {
 	Config *telegraf.CommonOutputConfig
 	Output telegraf.Output
+	Name   string
+
+	// BatchReady receives a value when a full batch is buffered.
+	BatchReady chan struct{} `json:"-"`
+
+	filter    *Filter
+	buffer    *Buffer
+	batchSize int
+	writeMu   sync.Mutex
+
+	metricsAdded   uint64
+	metricsWritten uint64
+	metricsDropped uint64
+	droppedPending uint64
+}
+
+// OutputStats are the metric counters of a RunningOutput.
+type OutputStats struct {
+	MetricsAdded   uint64
+	MetricsWritten uint64
+	MetricsDropped uint64
 }
 
 func NewRunningOutputs(
@@ -19,14 +53,126 @@ func NewRunningOutputs(
 	config *telegraf.OutputConfig,
 	registry telegraf.Registry,
 ) ([]*RunningOutput, error) {
+	if config.Config.FlushInterval.Duration < 0 {
+		return nil, fmt.Errorf("outputs.%s: flush_interval must not be negative", name)
+	}
+
+	filter, err := NewFilter(&config.Config.FilterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("outputs.%s: %v", name, err)
+	}
+
 	outputs, err := registry.CreateOutputs(name, config.PluginConfig)
 	if err != nil {
 		return nil, err
 	}
 
+	for _, output := range outputs {
+		switch output := output.(type) {
+		case telegraf.SerializerOutput:
+			serializerName := "influx"
+			if config.Config.DataFormat != "" {
+				serializerName = config.Config.DataFormat
+			}
+			serializer, err := registry.CreateSerializer(serializerName, config.SerializerConfig)
+			if err != nil {
+				return nil, err
+			}
+			output.SetSerializer(serializer)
+		default:
+			if len(config.SerializerOptions) > 0 {
+				return nil, fmt.Errorf("outputs.%s: output does not use a serializer, invalid options: %s",
+					name, strings.Join(config.SerializerOptions, ", "))
+			}
+		}
+	}
+
+	batchSize := config.Config.MetricBatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultMetricBatchSize
+	}
+
+	bufferLimit := config.Config.MetricBufferLimit
+	if bufferLimit <= 0 {
+		bufferLimit = DefaultMetricBufferLimit
+	}
+	if bufferLimit < batchSize {
+		bufferLimit = batchSize
+	}
+
 	r := make([]*RunningOutput, len(outputs))
 	for i, output := range outputs {
-		r[i] = &RunningOutput{config.Config, output}
+		r[i] = &RunningOutput{
+			Config:     config.Config,
+			Output:     output,
+			Name:       name,
+			BatchReady: make(chan struct{}, 1),
+			filter:     filter,
+			buffer:     NewBuffer(bufferLimit),
+			batchSize:  batchSize,
+		}
 	}
 	return r, nil
 }
+
+// AddMetric filters a metric and adds it to the buffer of the output.
+func (r *RunningOutput) AddMetric(m telegraf.Metric) {
+	m = r.filter.Apply(m)
+	if m == nil {
+		return
+	}
+
+	atomic.AddUint64(&r.metricsAdded, 1)
+	r.dropped(r.buffer.Add(m))
+
+	if r.buffer.Len() >= r.batchSize {
+		select {
+		case r.BatchReady <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Write writes the buffered metrics to the output in batches.  When a batch
+// fails it is returned to the buffer, to be retried on the next Write, and
+// the remaining batches are not attempted.
+func (r *RunningOutput) Write() error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	if dropped := atomic.SwapUint64(&r.droppedPending, 0); dropped > 0 {
+		fmt.Printf("W! [outputs.%s] metric buffer overflow; %d metrics have been dropped\n",
+			r.Name, dropped)
+	}
+
+	// Metrics added during the Write are left for the next Write.
+	pending := r.buffer.Len()
+	for pending > 0 {
+		batch := r.buffer.Batch(r.batchSize)
+		pending -= len(batch)
+
+		err := r.Output.Write(batch)
+		if err != nil {
+			r.dropped(r.buffer.Return(batch))
+			return err
+		}
+		atomic.AddUint64(&r.metricsWritten, uint64(len(batch)))
+	}
+	return nil
+}
+
+// Stats returns the metric counters of the output.
+func (r *RunningOutput) Stats() OutputStats {
+	return OutputStats{
+		MetricsAdded:   atomic.LoadUint64(&r.metricsAdded),
+		MetricsWritten: atomic.LoadUint64(&r.metricsWritten),
+		MetricsDropped: atomic.LoadUint64(&r.metricsDropped),
+	}
+}
+
+func (r *RunningOutput) dropped(n int) {
+	if n > 0 {
+		atomic.AddUint64(&r.metricsDropped, uint64(n))
+		atomic.AddUint64(&r.droppedPending, uint64(n))
+	}
+}
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/metric"
+)
+
+type testOutputConfig struct{}
+
+// testOutput is an Output without a Serializer.  It records the names of
+// the metrics of each batch written, and fails while fail is set.
+type testOutput struct {
+	fail    bool
+	batches [][]string
+}
+
+func newTestOutput(config *testOutputConfig) ([]telegraf.Output, error) {
+	return []telegraf.Output{&testOutput{}}, nil
+}
+
+func (o *testOutput) Connect() error {
+	return nil
+}
+
+func (o *testOutput) Close() error {
+	return nil
+}
+
+func (o *testOutput) Write(metrics []telegraf.Metric) error {
+	if o.fail {
+		return errors.New("write failed")
+	}
+	var names []string
+	for _, m := range metrics {
+		names = append(names, m.Name())
+	}
+	o.batches = append(o.batches, names)
+	return nil
+}
+
+func newTestRunningOutput(t *testing.T, batchSize int, bufferLimit int) (*RunningOutput, *testOutput) {
+	t.Helper()
+	empty := map[string]telegraf.PluginFactory{}
+	registry, err := NewRegistry(
+		empty, empty,
+		map[string]telegraf.PluginFactory{"test": newTestOutput},
+		empty, empty, empty, empty,
+		map[string]telegraf.ConfigParserFactory{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &telegraf.OutputConfig{
+		Config: &telegraf.CommonOutputConfig{
+			MetricBatchSize:   batchSize,
+			MetricBufferLimit: bufferLimit,
+		},
+		PluginConfig: &testOutputConfig{},
+	}
+	outputs, err := NewRunningOutputs("test", config, registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return outputs[0], outputs[0].Output.(*testOutput)
+}
+
+func addTestMetrics(t *testing.T, r *RunningOutput, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		m, err := metric.New(name, nil, map[string]interface{}{"value": 1.0}, time.Unix(0, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.AddMetric(m)
+	}
+}
+
+func TestRunningOutputRetry(t *testing.T) {
+	r, output := newTestRunningOutput(t, 2, 10)
+	addTestMetrics(t, r, "a", "b", "c")
+
+	output.fail = true
+	if err := r.Write(); err == nil {
+		t.Fatal("Write() succeeded with a failing output")
+	}
+	if len(output.batches) != 0 {
+		t.Fatalf("batches = %q, want none", output.batches)
+	}
+
+	// The failed batch is retried ahead of the metrics added since.
+	addTestMetrics(t, r, "d")
+	output.fail = false
+	if err := r.Write(); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"a", "b"}, {"c", "d"}}
+	if !reflect.DeepEqual(output.batches, want) {
+		t.Errorf("batches = %q, want %q", output.batches, want)
+	}
+
+	wantStats := OutputStats{MetricsAdded: 4, MetricsWritten: 4}
+	if stats := r.Stats(); stats != wantStats {
+		t.Errorf("Stats() = %+v, want %+v", stats, wantStats)
+	}
+}
+
+func TestRunningOutputDropped(t *testing.T) {
+	r, output := newTestRunningOutput(t, 2, 3)
+	addTestMetrics(t, r, "a", "b", "c", "d", "e")
+
+	if err := r.Write(); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"c", "d"}, {"e"}}
+	if !reflect.DeepEqual(output.batches, want) {
+		t.Errorf("batches = %q, want %q", output.batches, want)
+	}
+
+	wantStats := OutputStats{MetricsAdded: 5, MetricsWritten: 3, MetricsDropped: 2}
+	if stats := r.Stats(); stats != wantStats {
+		t.Errorf("Stats() = %+v, want %+v", stats, wantStats)
+	}
+	if pending := r.droppedPending; pending != 0 {
+		t.Errorf("droppedPending = %d after Write, want 0", pending)
+	}
+}
+
+func TestNewRunningOutputsSerializerOptions(t *testing.T) {
+	empty := map[string]telegraf.PluginFactory{}
+	registry, err := NewRegistry(
+		empty, empty,
+		map[string]telegraf.PluginFactory{"test": newTestOutput},
+		empty, empty, empty, empty,
+		map[string]telegraf.ConfigParserFactory{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &telegraf.OutputConfig{
+		Config:            &telegraf.CommonOutputConfig{},
+		PluginConfig:      &testOutputConfig{},
+		SerializerOptions: []string{"data_format"},
+	}
+	_, err = NewRunningOutputs("test", config, registry)
+	want := "outputs.test: output does not use a serializer, invalid options: data_format"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("NewRunningOutputs() error = %v, want %q", err, want)
+	}
+
+	config.SerializerOptions = nil
+	if _, err := NewRunningOutputs("test", config, registry); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+// RunningProcessor ensures only the metrics selected by the filter are
+// passed to the Processor.
+//
+// Existing: internal/models/running_processor.RunningProcessor
+type RunningProcessor struct {
+	Config    *telegraf.CommonProcessorConfig
+	Processor telegraf.Processor
+	Name      string
+
+	filter *Filter
+}
+
+func NewRunningProcessors(
+	name string,
+	config *telegraf.ProcessorConfig,
+	registry telegraf.Registry,
+) ([]*RunningProcessor, error) {
+	filter, err := NewFilter(&telegraf.FilterConfig{
+		SelectConfig: config.Config.SelectConfig,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("processors.%s: %v", name, err)
+	}
+
+	processors, err := registry.CreateProcessors(name, config.PluginConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	r := make([]*RunningProcessor, len(processors))
+	for i, processor := range processors {
+		r[i] = &RunningProcessor{
+			Config:    config.Config,
+			Processor: processor,
+			Name:      name,
+			filter:    filter,
+		}
+	}
+	return r, nil
+}
+
+// Apply passes the selected metrics to the Processor, the others are
+// returned unchanged.
+func (r *RunningProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	out := make([]telegraf.Metric, 0, len(in))
+	selected := make([]telegraf.Metric, 0, len(in))
+	for _, m := range in {
+		if r.filter.Select(m) {
+			selected = append(selected, m)
+		} else {
+			out = append(out, m)
+		}
+	}
+
+	if len(selected) > 0 {
+		out = append(out, r.Processor.Apply(selected...)...)
+	}
+	return out
+}
+
+// ProcessorChain is the RunningProcessors in the order they are applied.
+type ProcessorChain []*RunningProcessor
+
+// NewProcessorChain sorts the processors by their Order.  Processors with
+// the same Order are kept in the given order.
+func NewProcessorChain(processors []*RunningProcessor) ProcessorChain {
+	chain := make(ProcessorChain, len(processors))
+	copy(chain, processors)
+	sort.SliceStable(chain, func(i, j int) bool {
+		return chain[i].Config.Order < chain[j].Config.Order
+	})
+	return chain
+}
+
+// Apply applies each processor of the chain in turn.
+func (c ProcessorChain) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	metrics := in
+	for _, processor := range c {
+		metrics = processor.Apply(metrics...)
+		if len(metrics) == 0 {
+			break
+		}
+	}
+	return metrics
+}
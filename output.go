@@ -3,4 +3,18 @@ package telegraf
 // Output is an output plugin
 type Output interface {
 	Connect() error
+
+	// Close releases the resources of the output, it is called after the
+	// final Write.
+	Close() error
+
+	// Write writes a batch of metrics.  If an error is returned the batch
+	// will be retried.
+	Write(metrics []Metric) error
+}
+
+// plugins/serializers/registry.go
+type SerializerOutput interface {
+	// SetSerializer sets the serializer function for the interface
+	SetSerializer(serializer Serializer)
 }
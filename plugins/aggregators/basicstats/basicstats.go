@@ -0,0 +1,137 @@
+package basicstats
+
+import (
+	"fmt"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+const (
+	Name = "basicstats"
+)
+
+var defaultStats = []string{"count", "min", "max", "mean"}
+
+// Config contains the configuration for BasicStats.
+type Config struct {
+	// Stats lists the statistics to compute for each field, any of count,
+	// min, max, mean and sum; defaults to count, min, max and mean.
+	Stats []string `toml:"stats"`
+}
+
+// BasicStats is an aggregator that computes simple statistics of the
+// numeric fields of each series.
+type BasicStats struct {
+	stats  []string
+	series map[uint64]*aggregate
+}
+
+// aggregate is the statistics of a single series.
+type aggregate struct {
+	name   string
+	tags   map[string]string
+	fields map[string]*stats
+}
+
+type stats struct {
+	count int64
+	min   float64
+	max   float64
+	sum   float64
+}
+
+// New creates a BasicStats from a Config.
+func New(config *Config) ([]telegraf.Aggregator, error) {
+	names := config.Stats
+	if len(names) == 0 {
+		names = defaultStats
+	}
+	for _, name := range names {
+		switch name {
+		case "count", "min", "max", "mean", "sum":
+		default:
+			return nil, fmt.Errorf("basicstats: unknown stat: %s", name)
+		}
+	}
+
+	b := &BasicStats{stats: names}
+	b.Reset()
+	return []telegraf.Aggregator{b}, nil
+}
+
+func (b *BasicStats) Add(in telegraf.Metric) {
+	id := in.HashID()
+	a, ok := b.series[id]
+	if !ok {
+		a = &aggregate{
+			name:   in.Name(),
+			tags:   in.Tags(),
+			fields: make(map[string]*stats),
+		}
+		b.series[id] = a
+	}
+
+	for _, field := range in.FieldList() {
+		value, ok := convert(field.Value)
+		if !ok {
+			continue
+		}
+
+		s, ok := a.fields[field.Key]
+		if !ok {
+			a.fields[field.Key] = &stats{count: 1, min: value, max: value, sum: value}
+			continue
+		}
+		s.count++
+		s.sum += value
+		if value < s.min {
+			s.min = value
+		}
+		if value > s.max {
+			s.max = value
+		}
+	}
+}
+
+func (b *BasicStats) Push(acc telegraf.Accumulator) {
+	for _, a := range b.series {
+		fields := make(map[string]interface{})
+		for key, s := range a.fields {
+			for _, name := range b.stats {
+				switch name {
+				case "count":
+					fields[key+"_count"] = s.count
+				case "min":
+					fields[key+"_min"] = s.min
+				case "max":
+					fields[key+"_max"] = s.max
+				case "mean":
+					fields[key+"_mean"] = s.sum / float64(s.count)
+				case "sum":
+					fields[key+"_sum"] = s.sum
+				}
+			}
+		}
+		if len(fields) > 0 {
+			acc.AddFields(a.name, fields, a.tags)
+		}
+	}
+}
+
+func (b *BasicStats) Reset() {
+	b.series = make(map[uint64]*aggregate)
+}
+
+// convert returns the value of a numeric field as a float64.
+func convert(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
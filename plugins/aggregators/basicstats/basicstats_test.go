@@ -0,0 +1,115 @@
+package basicstats
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/metric"
+)
+
+type testMetric struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+}
+
+// testAccumulator records the metrics added to it by series.
+type testAccumulator struct {
+	metrics map[string]testMetric
+}
+
+func (a *testAccumulator) AddFields(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	if a.metrics == nil {
+		a.metrics = make(map[string]testMetric)
+	}
+	a.metrics[measurement+","+tags["host"]] = testMetric{measurement, tags, fields}
+}
+
+func (a *testAccumulator) AddGauge(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.AddFields(measurement, fields, tags, t...)
+}
+
+func (a *testAccumulator) AddCounter(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.AddFields(measurement, fields, tags, t...)
+}
+
+func (a *testAccumulator) AddMetric(m telegraf.Metric) {
+	a.AddFields(m.Name(), m.Fields(), m.Tags())
+}
+
+func (a *testAccumulator) AddError(err error) {}
+
+func TestBasicStats(t *testing.T) {
+	input := []testMetric{
+		{"cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 1.0, "state": "up"}},
+		{"cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": int64(5)}},
+		{"cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": uint64(3), "ok": true}},
+		{"cpu", map[string]string{"host": "b"}, map[string]interface{}{"usage": -2.0}},
+		{"mem", nil, map[string]interface{}{"state": "up"}},
+	}
+
+	tests := []struct {
+		name  string
+		stats []string
+		want  map[string]testMetric
+	}{
+		{
+			name: "default stats",
+			want: map[string]testMetric{
+				"cpu,a": {"cpu", map[string]string{"host": "a"}, map[string]interface{}{
+					"usage_count": int64(3), "usage_min": 1.0, "usage_max": 5.0, "usage_mean": 3.0,
+				}},
+				"cpu,b": {"cpu", map[string]string{"host": "b"}, map[string]interface{}{
+					"usage_count": int64(1), "usage_min": -2.0, "usage_max": -2.0, "usage_mean": -2.0,
+				}},
+			},
+		},
+		{
+			name:  "selected stats",
+			stats: []string{"sum"},
+			want: map[string]testMetric{
+				"cpu,a": {"cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage_sum": 9.0}},
+				"cpu,b": {"cpu", map[string]string{"host": "b"}, map[string]interface{}{"usage_sum": -2.0}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aggregators, err := New(&Config{Stats: tt.stats})
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := aggregators[0]
+			for _, tm := range input {
+				m, err := metric.New(tm.name, tm.tags, tm.fields, time.Unix(0, 0))
+				if err != nil {
+					t.Fatal(err)
+				}
+				b.Add(m)
+			}
+
+			acc := &testAccumulator{}
+			b.Push(acc)
+			if !reflect.DeepEqual(acc.metrics, tt.want) {
+				t.Errorf("pushed %v, want %v", acc.metrics, tt.want)
+			}
+
+			b.Reset()
+			acc = &testAccumulator{}
+			b.Push(acc)
+			if len(acc.metrics) != 0 {
+				t.Errorf("pushed %v after Reset, want nothing", acc.metrics)
+			}
+		})
+	}
+}
+
+func TestNewUnknownStat(t *testing.T) {
+	_, err := New(&Config{Stats: []string{"median"}})
+	if err == nil || !strings.Contains(err.Error(), "unknown stat: median") {
+		t.Fatalf("New() error = %v, want unknown stat", err)
+	}
+}
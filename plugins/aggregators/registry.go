@@ -0,0 +1,9 @@
+package aggregators
+
+import (
+	"github.com/influxdata/tgconfig/plugins/aggregators/basicstats"
+)
+
+var Aggregators = map[string]interface{}{
+	basicstats.Name: basicstats.New,
+}
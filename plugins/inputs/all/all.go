@@ -3,4 +3,5 @@ package all
 import (
 	_ "github.com/influxdata/tgconfig/plugins/inputs/example"
 	_ "github.com/influxdata/tgconfig/plugins/inputs/example2"
+	_ "github.com/influxdata/tgconfig/plugins/inputs/memstats"
 )
@@ -15,7 +15,7 @@ type Config struct {
 type Example struct {
 	Config Config
 
-	parser telegraf.Parser
+	parserFunc telegraf.ParserFunc
 }
 
 // New creates an Example from a Config.
@@ -23,12 +23,21 @@ func New(config *Config) ([]telegraf.Input, error) {
 	return []telegraf.Input{&Example{Config: *config}}, nil
 }
 
-func (p *Example) Gather() error {
+func (p *Example) Gather(acc telegraf.Accumulator) error {
+	fields := map[string]interface{}{
+		"value": p.Config.Value,
+	}
+	acc.AddFields("example", fields, nil)
 	return nil
 }
 
-func (p *Example) SetParser(parser telegraf.Parser) {
-	p.parser = parser
+func (p *Example) SetParserFunc(fn telegraf.ParserFunc) {
+	p.parserFunc = fn
+}
+
+// DataFormat returns "influx", used when data_format is not set.
+func (p *Example) DataFormat() string {
+	return "influx"
 }
 
 func init() {
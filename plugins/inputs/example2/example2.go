@@ -21,7 +21,11 @@ func New(config *Config) ([]telegraf.Input, error) {
 	return []telegraf.Input{&Example2{config.Value}}, nil
 }
 
-func (p *Example2) Gather() error {
+func (p *Example2) Gather(acc telegraf.Accumulator) error {
+	fields := map[string]interface{}{
+		"value": p.Value,
+	}
+	acc.AddFields("example2", fields, nil)
 	return nil
 }
 
@@ -0,0 +1,50 @@
+package memstats
+
+import (
+	"runtime"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/plugins/inputs"
+)
+
+const (
+	Name = "memstats"
+)
+
+// Config contains the configuration for Memstats.
+type Config struct {
+}
+
+// Memstats is an input that reports the memory statistics of the agent
+// itself.
+type Memstats struct {
+}
+
+// New creates a Memstats from a Config.
+func New(config *Config) ([]telegraf.Input, error) {
+	return []telegraf.Input{&Memstats{}}, nil
+}
+
+func (p *Memstats) Gather(acc telegraf.Accumulator) error {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	fields := map[string]interface{}{
+		"alloc_bytes":       m.Alloc,
+		"total_alloc_bytes": m.TotalAlloc,
+		"sys_bytes":         m.Sys,
+		"mallocs":           m.Mallocs,
+		"frees":             m.Frees,
+		"heap_alloc_bytes":  m.HeapAlloc,
+		"heap_in_use_bytes": m.HeapInuse,
+		"heap_objects":      m.HeapObjects,
+		"num_gc":            m.NumGC,
+		"goroutines":        runtime.NumGoroutine(),
+	}
+	acc.AddFields("memstats", fields, nil)
+	return nil
+}
+
+func init() {
+	inputs.Add(Name, New)
+}
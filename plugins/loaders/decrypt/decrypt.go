@@ -0,0 +1,23 @@
+// Package decrypt opens the encrypted configs read by Loaders.
+package decrypt
+
+import (
+	"github.com/influxdata/tgconfig/internal/envelope"
+)
+
+// Config is embedded in the config of Loaders that read config documents,
+// so that every such Loader accepts encrypted configs.
+type Config struct {
+	// KeyFile contains the key used to decrypt an encrypted config.
+	KeyFile string `toml:"key_file"`
+	// KeyEnv is the environment variable containing the key used to decrypt
+	// an encrypted config, used when KeyFile is not set.
+	KeyEnv string `toml:"key_env"`
+}
+
+// Open returns data unchanged if it is not encrypted, otherwise it is
+// decrypted with the configured key.  Signatures are over the config as
+// stored, so Open is called after verification.
+func (c *Config) Open(data []byte) ([]byte, error) {
+	return envelope.Open(data, c.KeyFile, c.KeyEnv)
+}
@@ -0,0 +1,94 @@
+package decrypt
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/tgconfig/internal/envelope"
+)
+
+func TestOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "decrypt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	encoded, err := envelope.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "telegraf.key")
+	if err := ioutil.WriteFile(keyFile, encoded, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	const env = "TGCONFIG_TEST_DECRYPT_KEY"
+	os.Setenv(env, string(encoded))
+	defer os.Unsetenv(env)
+
+	plaintext := []byte("[agent]\n  interval = \"10s\"\n")
+	sealed, err := envelope.Encrypt(key, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		config  Config
+		data    []byte
+		wantErr string
+	}{
+		{
+			name: "plaintext",
+			data: plaintext,
+		},
+		{
+			name:   "key file",
+			config: Config{KeyFile: keyFile},
+			data:   sealed,
+		},
+		{
+			name:   "key env",
+			config: Config{KeyEnv: env},
+			data:   sealed,
+		},
+		{
+			name:    "unset key env",
+			config:  Config{KeyEnv: env + "_UNSET"},
+			data:    sealed,
+			wantErr: "is not set",
+		},
+		{
+			name:    "missing key file",
+			config:  Config{KeyFile: filepath.Join(dir, "missing.key")},
+			data:    sealed,
+			wantErr: "no such file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.config.Open(tt.data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Open() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(plaintext) {
+				t.Errorf("Open() = %q, want %q", got, plaintext)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package exec
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/plugins/loaders/decrypt"
+)
+
+const (
+	Name = "exec"
+)
+
+const (
+	defaultDataFormat   = "toml"
+	defaultTimeout      = 5 * time.Second
+	defaultPollInterval = time.Minute
+)
+
+// Config contains the configuration for the Exec loader.
+type Config struct {
+	// Command is the program that writes the config to stdout.
+	Command string `toml:"command"`
+	// Args are the arguments passed to Command.
+	Args []string `toml:"args"`
+	// Environment contains additional "KEY=value" pairs for Command.
+	Environment []string `toml:"environment"`
+	// DataFormat is the format of the config written by Command, defaults
+	// to "toml".
+	DataFormat string `toml:"data_format"`
+	// Timeout limits how long Command may run when loading.
+	Timeout telegraf.Duration `toml:"timeout"`
+	// PollInterval is how often Command is rerun to check for changes.
+	PollInterval telegraf.Duration `toml:"poll_interval"`
+	// ChangeMarker, if set, disables polling.  Instead Command is run with
+	// WatchArgs and the watch completes when it prints a line equal to
+	// ChangeMarker.
+	ChangeMarker string `toml:"change_marker"`
+	// WatchArgs are the arguments passed to Command when watching for the
+	// ChangeMarker.
+	WatchArgs []string `toml:"watch_args"`
+	// SignatureArgs are the arguments passed to Command to obtain the
+	// detached signature of the config when verification is enabled.
+	SignatureArgs []string `toml:"signature_args"`
+
+	decrypt.Config
+}
+
+// Exec is a Loader that obtains the config from the output of a command.
+type Exec struct {
+	Config Config
+
+	verifier telegraf.Verifier
+
+	// hash is the hash of the output of the last Load, the baseline the
+	// PollWaiter compares against.
+	mu     sync.Mutex
+	hash   [sha256.Size]byte
+	loaded bool
+}
+
+func New(config *Config) ([]telegraf.Loader, error) {
+	if config.Command == "" {
+		return nil, fmt.Errorf("exec loader: command is required")
+	}
+
+	c := *config
+	if c.DataFormat == "" {
+		c.DataFormat = defaultDataFormat
+	}
+	if c.Timeout.Duration <= 0 {
+		c.Timeout.Duration = defaultTimeout
+	}
+	if c.PollInterval.Duration <= 0 {
+		c.PollInterval.Duration = defaultPollInterval
+	}
+	return []telegraf.Loader{&Exec{Config: c}}, nil
+}
+
+func (c *Exec) Load(ctx context.Context, registry telegraf.ConfigRegistry) (*telegraf.Config, error) {
+	parser, ok := registry.GetConfigParser(c.Config.DataFormat)
+	if !ok {
+		return nil, fmt.Errorf("exec loader: unknown data_format: %s", c.Config.DataFormat)
+	}
+
+	out, err := c.run(ctx, c.Config.Args)
+	if err != nil {
+		return nil, err
+	}
+	c.setHash(sha256.Sum256(out))
+
+	if c.verifier != nil {
+		sig, err := c.run(ctx, c.Config.SignatureArgs)
+		if err != nil {
+			return nil, err
+		}
+
+		err = c.verifier.Verify(out, sig)
+		if err != nil {
+			return nil, fmt.Errorf("exec loader: %s: %v", c.Config.Command, err)
+		}
+	}
+
+	out, err = c.Config.Open(out)
+	if err != nil {
+		return nil, fmt.Errorf("exec loader: %s: %v", c.Config.Command, err)
+	}
+
+	return parser.Parse(bytes.NewReader(out))
+}
+
+// SetVerifier sets the Verifier, the signature is the output of Command
+// run with SignatureArgs.
+func (c *Exec) SetVerifier(verifier telegraf.Verifier) error {
+	if len(c.Config.SignatureArgs) == 0 {
+		return fmt.Errorf("exec loader: signature_args is required to verify signatures")
+	}
+	c.verifier = verifier
+	return nil
+}
+
+func (c *Exec) Watch(ctx context.Context) (telegraf.Waiter, error) {
+	if c.Config.ChangeMarker != "" {
+		return NewMarkerWaiter(ctx, c.command(ctx, c.Config.WatchArgs), c.Config.ChangeMarker)
+	}
+	return NewPollWaiter(ctx, c)
+}
+
+func (c *Exec) setHash(hash [sha256.Size]byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hash = hash
+	c.loaded = true
+}
+
+// loadedHash returns the hash of the output of the last Load, false if the
+// config has not been loaded yet.
+func (c *Exec) loadedHash() ([sha256.Size]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hash, c.loaded
+}
+
+func (c *Exec) command(ctx context.Context, args []string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, c.Config.Command, args...)
+	cmd.Env = append(os.Environ(), c.Config.Environment...)
+	return cmd
+}
+
+// run executes the command and returns its stdout.
+func (c *Exec) run(ctx context.Context, args []string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.Config.Timeout.Duration)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := c.command(ctx, args)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("exec loader: %s timed out after %s",
+			c.Config.Command, c.Config.Timeout.Duration)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("exec loader: %s: %v: %s",
+			c.Config.Command, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// PollWaiter reruns the command on an interval and completes when the hash
+// of its output differs from the output read by the last Load.  Nothing is
+// compared until the config has been loaded.
+type PollWaiter struct {
+	ctx context.Context
+	wg  sync.WaitGroup
+}
+
+func NewPollWaiter(ctx context.Context, loader *Exec) (*PollWaiter, error) {
+	w := &PollWaiter{ctx: ctx}
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(loader.Config.PollInterval.Duration)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				out, err := loader.run(ctx, loader.Config.Args)
+				if err != nil {
+					// Keep the current config until the command recovers.
+					fmt.Println(err)
+					continue
+				}
+				hash, ok := loader.loadedHash()
+				if ok && sha256.Sum256(out) != hash {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return w, nil
+}
+
+func (w *PollWaiter) Wait() error {
+	w.wg.Wait()
+	return w.ctx.Err()
+}
+
+// MarkerWaiter runs a long lived command and completes when it prints the
+// change marker.
+type MarkerWaiter struct {
+	ctx context.Context
+	wg  sync.WaitGroup
+	err error
+}
+
+func NewMarkerWaiter(ctx context.Context, cmd *exec.Cmd, marker string) (*MarkerWaiter, error) {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	w := &MarkerWaiter{ctx: ctx}
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		scanner := bufio.NewScanner(stdout)
+		found := false
+		for scanner.Scan() {
+			if strings.TrimSpace(scanner.Text()) == marker {
+				found = true
+				break
+			}
+		}
+
+		// The command is no longer needed once the marker has been seen.
+		if cmd.Process != nil {
+			cmd.Process.Kill()
+		}
+		err := cmd.Wait()
+		if !found && ctx.Err() == nil {
+			w.err = fmt.Errorf("exec loader: %s exited before change: %v", cmd.Path, err)
+		}
+	}()
+	return w, nil
+}
+
+func (w *MarkerWaiter) Wait() error {
+	w.wg.Wait()
+	if w.err != nil {
+		return w.err
+	}
+	return w.ctx.Err()
+}
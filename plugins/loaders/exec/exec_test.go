@@ -0,0 +1,222 @@
+package exec
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+type testVerifier struct{}
+
+func (testVerifier) Verify(data, signature []byte) error {
+	return nil
+}
+
+func TestSetVerifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{
+			name:   "signature args",
+			config: Config{Command: "cat", SignatureArgs: []string{"config.sig"}},
+		},
+		{
+			name:    "no signature args",
+			config:  Config{Command: "cat"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaders, err := New(&tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			loader := loaders[0].(*Exec)
+			err = loader.SetVerifier(testVerifier{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetVerifier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (loader.verifier != nil) == tt.wantErr {
+				t.Fatalf("verifier set = %v", loader.verifier != nil)
+			}
+		})
+	}
+}
+
+// testConfigParser records the document it parses.
+type testConfigParser struct {
+	doc string
+}
+
+func (p *testConfigParser) Parse(reader io.Reader) (*telegraf.Config, error) {
+	buf, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	p.doc = string(buf)
+	return &telegraf.Config{}, nil
+}
+
+type testConfigRegistry map[string]*testConfigParser
+
+func (r testConfigRegistry) GetPluginConfig(pluginType telegraf.PluginType, name string) (telegraf.PluginConfig, bool) {
+	return nil, false
+}
+
+func (r testConfigRegistry) GetConfigParser(format string) (telegraf.ConfigParser, bool) {
+	parser, ok := r[format]
+	return parser, ok
+}
+
+func TestLoadDataFormat(t *testing.T) {
+	tests := []struct {
+		name       string
+		dataFormat string
+		want       string
+		wantErr    string
+	}{
+		{
+			name: "default",
+			want: "toml",
+		},
+		{
+			name:       "json",
+			dataFormat: "json",
+			want:       "json",
+		},
+		{
+			name:       "unknown",
+			dataFormat: "yaml",
+			wantErr:    "unknown data_format: yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := testConfigRegistry{
+				"toml": &testConfigParser{},
+				"json": &testConfigParser{},
+			}
+			loaders, err := New(&Config{
+				Command:    "echo",
+				Args:       []string{"config"},
+				DataFormat: tt.dataFormat,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = loaders[0].Load(context.Background(), registry)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for format, parser := range registry {
+				want := ""
+				if format == tt.want {
+					want = "config\n"
+				}
+				if parser.doc != want {
+					t.Errorf("%s parser got %q, want %q", format, parser.doc, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigKeys(t *testing.T) {
+	var config Config
+	_, err := toml.Decode(`
+command = "fetch-config"
+args = ["--format", "toml"]
+environment = ["TOKEN=x"]
+timeout = "2s"
+`, &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Config{
+		Command:     "fetch-config",
+		Args:        []string{"--format", "toml"},
+		Environment: []string{"TOKEN=x"},
+		Timeout:     telegraf.Duration{Duration: 2 * time.Second},
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("config = %+v, want %+v", config, want)
+	}
+}
+
+func TestPollWaiter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config")
+	if err := ioutil.WriteFile(path, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaders, err := New(&Config{
+		Command:      "cat",
+		Args:         []string{path},
+		PollInterval: telegraf.Duration{Duration: 10 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loader := loaders[0]
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	waiter, err := loader.Watch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- waiter.Wait() }()
+
+	// The output is compared to what was loaded, polling alone does not
+	// complete the watch.
+	time.Sleep(50 * time.Millisecond)
+	registry := testConfigRegistry{"toml": &testConfigParser{}}
+	if _, err := loader.Load(ctx, registry); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("watch completed without a change: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := ioutil.WriteFile(path, []byte("b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not complete after the output changed")
+	}
+}
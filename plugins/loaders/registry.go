@@ -1,12 +1,21 @@
 package loaders
 
 import (
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/plugins/loaders/exec"
 	"github.com/influxdata/tgconfig/plugins/loaders/null"
 	"github.com/influxdata/tgconfig/plugins/loaders/toml"
 )
 
 var Loaders = map[string]interface{}{
+	exec.Name:     exec.New,
 	null.Name:     null.New,
 	toml.Name:     toml.New,
 	toml.HTTPName: toml.NewHTTP,
 }
+
+// Formats are the config formats, used by Loaders that support more than
+// one.
+var Formats = map[string]telegraf.ConfigParserFactory{
+	toml.Name: toml.NewConfigParser,
+}
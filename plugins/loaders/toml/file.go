@@ -1,13 +1,17 @@
 package toml
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
 
 	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/plugins/loaders/decrypt"
 )
 
 const (
@@ -16,6 +20,8 @@ const (
 
 type Toml struct {
 	Config Config
+
+	verifier telegraf.Verifier
 }
 
 type Config struct {
@@ -23,6 +29,11 @@ type Config struct {
 	Path string
 	// Directory is an directory containing config snippets
 	Directory string
+	// SignaturePath is the detached signature of the main config file,
+	// defaults to Path with ".sig" appended.
+	SignaturePath string `toml:"signature_path"`
+
+	decrypt.Config
 }
 
 func New(config *Config) ([]telegraf.Loader, error) {
@@ -30,13 +41,41 @@ func New(config *Config) ([]telegraf.Loader, error) {
 }
 
 func (c *Toml) Load(ctx context.Context, registry telegraf.ConfigRegistry) (*telegraf.Config, error) {
-	reader, err := os.Open(c.Config.Path)
+	buf, err := ioutil.ReadFile(c.Config.Path)
 	if err != nil {
 		return nil, err
 	}
 
+	if c.verifier != nil {
+		sigPath := c.Config.SignaturePath
+		if sigPath == "" {
+			sigPath = c.Config.Path + ".sig"
+		}
+
+		// A missing signature is reported by the Verifier.
+		sig, err := ioutil.ReadFile(sigPath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		err = c.verifier.Verify(buf, sig)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", c.Config.Path, err)
+		}
+	}
+
+	buf, err = c.Config.Open(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", c.Config.Path, err)
+	}
+
 	parser := NewParser(registry)
-	return parser.Parse(reader)
+	return parser.Parse(bytes.NewReader(buf))
+}
+
+func (c *Toml) SetVerifier(verifier telegraf.Verifier) error {
+	c.verifier = verifier
+	return nil
 }
 
 func (c *Toml) Watch(ctx context.Context) (telegraf.Waiter, error) {
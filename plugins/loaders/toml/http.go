@@ -1,12 +1,15 @@
 package toml
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 
 	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/plugins/loaders/decrypt"
 )
 
 const (
@@ -15,11 +18,15 @@ const (
 
 type HTTPConfig struct {
 	Origin string
+
+	decrypt.Config
 }
 
 type HTTP struct {
-	origin *url.URL
-	client *http.Client
+	origin   *url.URL
+	client   *http.Client
+	decrypt  decrypt.Config
+	verifier telegraf.Verifier
 }
 
 func NewHTTP(config *HTTPConfig) ([]telegraf.Loader, error) {
@@ -35,15 +42,49 @@ func NewHTTP(config *HTTPConfig) ([]telegraf.Loader, error) {
 	}
 
 	http := &HTTP{
-		origin: origin,
-		client: client,
+		origin:  origin,
+		client:  client,
+		decrypt: config.Config,
 	}
 	return []telegraf.Loader{http}, nil
 }
 
 func (c *HTTP) Load(ctx context.Context, registry telegraf.ConfigRegistry) (*telegraf.Config, error) {
-	url := *c.origin
-	url.Path = "/config"
+	url := c.URLWithPath("/config")
+	buf, err := c.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.verifier != nil {
+		// The detached signature is served alongside the config.
+		sig, err := c.get(ctx, c.URLWithPath("/config.sig"))
+		if err != nil {
+			return nil, err
+		}
+
+		err = c.verifier.Verify(buf, sig)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", url, err)
+		}
+	}
+
+	buf, err = c.decrypt.Open(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", url, err)
+	}
+
+	parser := NewParser(registry)
+	return parser.Parse(bytes.NewReader(buf))
+}
+
+func (c *HTTP) SetVerifier(verifier telegraf.Verifier) error {
+	c.verifier = verifier
+	return nil
+}
+
+// get returns the body of a successful GET request.
+func (c *HTTP) get(ctx context.Context, url *url.URL) ([]byte, error) {
 	req, err := http.NewRequest("GET", url.String(), http.NoBody)
 	if err != nil {
 		return nil, err
@@ -55,8 +96,10 @@ func (c *HTTP) Load(ctx context.Context, registry telegraf.ConfigRegistry) (*tel
 	}
 	defer resp.Body.Close()
 
-	parser := NewParser(registry)
-	return parser.Parse(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status %s", url, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
 }
 
 func (c *HTTP) URLWithPath(path string) *url.URL {
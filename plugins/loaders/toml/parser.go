@@ -3,6 +3,9 @@ package toml
 import (
 	"fmt"
 	"io"
+	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 
@@ -18,15 +21,25 @@ func NewParser(registry telegraf.ConfigRegistry) *parser {
 	return &parser{registry: registry}
 }
 
+// NewConfigParser is the telegraf.ConfigParserFactory of the toml format.
+func NewConfigParser(registry telegraf.ConfigRegistry) telegraf.ConfigParser {
+	return NewParser(registry)
+}
+
 func (p *parser) Parse(reader io.Reader) (*telegraf.Config, error) {
 	var err error
 	conf := struct {
-		Agent   telegraf.AgentConfig
-		Inputs  map[string][]toml.Primitive
-		Outputs map[string][]toml.Primitive
-		Loaders map[string][]toml.Primitive
+		Agent       telegraf.AgentConfig
+		Inputs      map[string][]toml.Primitive
+		Outputs     map[string][]toml.Primitive
+		Processors  map[string][]toml.Primitive
+		Aggregators map[string][]toml.Primitive
+		Loaders     map[string][]toml.Primitive
 	}{}
 
+	// Settings not present in the file keep their default values.
+	conf.Agent = telegraf.DefaultAgentConfig()
+
 	if p.md, err = toml.DecodeReader(reader, &conf); err != nil {
 		return nil, err
 	}
@@ -41,6 +54,16 @@ func (p *parser) Parse(reader io.Reader) (*telegraf.Config, error) {
 		return nil, err
 	}
 
+	rp, err := p.loadProcessors(conf.Processors)
+	if err != nil {
+		return nil, err
+	}
+
+	ra, err := p.loadAggregators(conf.Aggregators)
+	if err != nil {
+		return nil, err
+	}
+
 	rl, err := p.loadLoaders(conf.Loaders)
 	if err != nil {
 		return nil, err
@@ -48,19 +71,24 @@ func (p *parser) Parse(reader io.Reader) (*telegraf.Config, error) {
 
 	// Now that we have tried to parse the entire file we report unrecognized plugins.
 	for _, item := range p.md.Undecoded() {
-		// Recursive config plugin loading is not allowed.
-		// edit: Still error though?
-		// if strings.HasPrefix(item.String(), "loaders.") {
-		// 	continue
-		// }
 		return nil, fmt.Errorf("undecoded toml key: %s", item)
 	}
 
 	config := &telegraf.Config{
-		Agent:   conf.Agent,
-		Inputs:  ri,
-		Outputs: ro,
-		Loaders: rl,
+		Inputs:      ri,
+		Outputs:     ro,
+		Processors:  rp,
+		Aggregators: ra,
+		Loaders:     rl,
+	}
+	if p.md.IsDefined("agent") {
+		config.Agent = &conf.Agent
+		config.AgentKeys = make([]string, 0)
+		for _, key := range telegraf.AgentConfigKeys() {
+			if p.md.IsDefined("agent", key) {
+				config.AgentKeys = append(config.AgentKeys, key)
+			}
+		}
 	}
 	return config, nil
 }
@@ -88,22 +116,50 @@ func (p *parser) loadInputs(inputs map[string][]toml.Primitive) (map[string][]*t
 			}
 
 			// We don't know if this plugin will have a parser until we new
-			// it, so we will just always try to load a parser just in case.
-			dataFormat := commonConfig.DataFormat
-			if dataFormat == "" {
-				dataFormat = "influx"
+			// it, so only the parser options present in the table are
+			// decoded.  They are recorded so they can be rejected if the
+			// plugin turns out not to have a parser.
+			keys, err := definedKeys(primitive)
+			if err != nil {
+				return nil, err
+			}
+			var parserOptions []string
+			var parserConfig telegraf.PluginConfig
+
+			// Without a data_format the input chooses its parser, and so
+			// parser options cannot be set.
+			if commonConfig.DataFormat != "" {
+				parserOptions = append(parserOptions, "data_format")
+
+				// Parse parser configuration
+				parserConfig, ok = p.registry.GetPluginConfig(telegraf.ParserType, commonConfig.DataFormat)
+				if !ok {
+					return nil, fmt.Errorf("unknown data format for input %s: %s", name, commonConfig.DataFormat)
+				}
+				for _, key := range configKeys(parserConfig) {
+					if keys[key] {
+						parserOptions = append(parserOptions, key)
+					}
+				}
+				if err := p.md.PrimitiveDecode(primitive, parserConfig); err != nil {
+					return nil, err
+				}
 			}
 
-			// Parse parser configuration
-			parserConfig, ok := p.registry.GetPluginConfig(telegraf.ParserType, dataFormat)
-			if err := p.md.PrimitiveDecode(primitive, parserConfig); err != nil {
+			parsers, err := p.loadNamedParsers(name, primitive)
+			if err != nil {
 				return nil, err
 			}
+			if len(parsers) > 0 {
+				parserOptions = append(parserOptions, "parsers")
+			}
 
 			plugin := &telegraf.InputConfig{
-				Config:       commonConfig,
-				PluginConfig: pluginConfig,
-				ParserConfig: parserConfig,
+				Config:        commonConfig,
+				PluginConfig:  pluginConfig,
+				ParserConfig:  parserConfig,
+				Parsers:       parsers,
+				ParserOptions: parserOptions,
 			}
 			configs = append(configs, plugin)
 		}
@@ -112,6 +168,54 @@ func (p *parser) loadInputs(inputs map[string][]toml.Primitive) (map[string][]*t
 	return inputConfigs, nil
 }
 
+// loadNamedParsers loads the parsers tables of an input, sorted by name.
+func (p *parser) loadNamedParsers(input string, primitive toml.Primitive) ([]*telegraf.NamedParserConfig, error) {
+	conf := struct {
+		Parsers map[string]toml.Primitive `toml:"parsers"`
+	}{}
+	if err := p.md.PrimitiveDecode(primitive, &conf); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(conf.Parsers))
+	for name := range conf.Parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parsers := make([]*telegraf.NamedParserConfig, 0, len(names))
+	for _, name := range names {
+		primitive := conf.Parsers[name]
+
+		commonConfig := &telegraf.CommonNamedParserConfig{}
+		if err := p.md.PrimitiveDecode(primitive, commonConfig); err != nil {
+			return nil, err
+		}
+		if commonConfig.DataFormat == "" {
+			return nil, fmt.Errorf("parser %s of input %s: data_format is required", name, input)
+		}
+		selector := commonConfig.ParserSelector
+		if len(selector.ContentTypes) == 0 && len(selector.Topics) == 0 && len(selector.FileExtensions) == 0 {
+			return nil, fmt.Errorf("parser %s of input %s: content_types, topics or file_extensions is required", name, input)
+		}
+
+		parserConfig, ok := p.registry.GetPluginConfig(telegraf.ParserType, commonConfig.DataFormat)
+		if !ok {
+			return nil, fmt.Errorf("unknown data format for parser %s of input %s: %s", name, input, commonConfig.DataFormat)
+		}
+		if err := p.md.PrimitiveDecode(primitive, parserConfig); err != nil {
+			return nil, err
+		}
+
+		parsers = append(parsers, &telegraf.NamedParserConfig{
+			Name:         name,
+			Config:       commonConfig,
+			ParserConfig: parserConfig,
+		})
+	}
+	return parsers, nil
+}
+
 func (p *parser) loadOutputs(outputs map[string][]toml.Primitive) (map[string][]*telegraf.OutputConfig, error) {
 	outputConfigs := make(map[string][]*telegraf.OutputConfig)
 
@@ -134,9 +238,41 @@ func (p *parser) loadOutputs(outputs map[string][]toml.Primitive) (map[string][]
 				return nil, err
 			}
 
+			// As with parsers, we don't know if the output will have a
+			// serializer until we new it.  The serializer options present in
+			// the table are recorded so they can be rejected if it does not.
+			keys, err := definedKeys(primitive)
+			if err != nil {
+				return nil, err
+			}
+			var serializerOptions []string
+			if keys["data_format"] {
+				serializerOptions = append(serializerOptions, "data_format")
+			}
+			dataFormat := commonConfig.DataFormat
+			if dataFormat == "" {
+				dataFormat = "influx"
+			}
+
+			// Parse serializer configuration
+			serializerConfig, ok := p.registry.GetPluginConfig(telegraf.SerializerType, dataFormat)
+			if !ok {
+				return nil, fmt.Errorf("unknown data format for output %s: %s", name, dataFormat)
+			}
+			for _, key := range configKeys(serializerConfig) {
+				if keys[key] {
+					serializerOptions = append(serializerOptions, key)
+				}
+			}
+			if err := p.md.PrimitiveDecode(primitive, serializerConfig); err != nil {
+				return nil, err
+			}
+
 			plugin := &telegraf.OutputConfig{
-				Config:       commonConfig,
-				PluginConfig: pluginConfig,
+				Config:            commonConfig,
+				PluginConfig:      pluginConfig,
+				SerializerConfig:  serializerConfig,
+				SerializerOptions: serializerOptions,
 			}
 			configs = append(configs, plugin)
 		}
@@ -145,6 +281,72 @@ func (p *parser) loadOutputs(outputs map[string][]toml.Primitive) (map[string][]
 	return outputConfigs, nil
 }
 
+func (p *parser) loadProcessors(processors map[string][]toml.Primitive) (map[string][]*telegraf.ProcessorConfig, error) {
+	processorConfigs := make(map[string][]*telegraf.ProcessorConfig)
+
+	for name, primitives := range processors {
+		configs := make([]*telegraf.ProcessorConfig, 0)
+		for _, primitive := range primitives {
+			pluginConfig, ok := p.registry.GetPluginConfig(telegraf.ProcessorType, name)
+			if !ok {
+				return nil, fmt.Errorf("unknown processor plugin: %s", name)
+			}
+
+			// Parse specific configuration
+			if err := p.md.PrimitiveDecode(primitive, pluginConfig); err != nil {
+				return nil, err
+			}
+
+			// Parse common configuration
+			commonConfig := &telegraf.CommonProcessorConfig{}
+			if err := p.md.PrimitiveDecode(primitive, commonConfig); err != nil {
+				return nil, err
+			}
+
+			plugin := &telegraf.ProcessorConfig{
+				Config:       commonConfig,
+				PluginConfig: pluginConfig,
+			}
+			configs = append(configs, plugin)
+		}
+		processorConfigs[name] = configs
+	}
+	return processorConfigs, nil
+}
+
+func (p *parser) loadAggregators(aggregators map[string][]toml.Primitive) (map[string][]*telegraf.AggregatorConfig, error) {
+	aggregatorConfigs := make(map[string][]*telegraf.AggregatorConfig)
+
+	for name, primitives := range aggregators {
+		configs := make([]*telegraf.AggregatorConfig, 0)
+		for _, primitive := range primitives {
+			pluginConfig, ok := p.registry.GetPluginConfig(telegraf.AggregatorType, name)
+			if !ok {
+				return nil, fmt.Errorf("unknown aggregator plugin: %s", name)
+			}
+
+			// Parse specific configuration
+			if err := p.md.PrimitiveDecode(primitive, pluginConfig); err != nil {
+				return nil, err
+			}
+
+			// Parse common configuration
+			commonConfig := &telegraf.CommonAggregatorConfig{}
+			if err := p.md.PrimitiveDecode(primitive, commonConfig); err != nil {
+				return nil, err
+			}
+
+			plugin := &telegraf.AggregatorConfig{
+				Config:       commonConfig,
+				PluginConfig: pluginConfig,
+			}
+			configs = append(configs, plugin)
+		}
+		aggregatorConfigs[name] = configs
+	}
+	return aggregatorConfigs, nil
+}
+
 func (p *parser) loadLoaders(loaders map[string][]toml.Primitive) (map[string][]*telegraf.LoaderConfig, error) {
 	loaderConfigs := make(map[string][]*telegraf.LoaderConfig, 0)
 
@@ -177,3 +379,42 @@ func (p *parser) loadLoaders(loaders map[string][]toml.Primitive) (map[string][]
 	}
 	return loaderConfigs, nil
 }
+
+// definedKeys returns the keys defined in the table of a primitive.  As the
+// metadata of the file does not distinguish the tables of an array, the keys
+// are decoded with separate metadata, which also leaves them undecoded in the
+// file.
+func definedKeys(primitive toml.Primitive) (map[string]bool, error) {
+	md, err := toml.Decode("", &struct{}{})
+	if err != nil {
+		return nil, err
+	}
+
+	var table map[string]toml.Primitive
+	if err := md.PrimitiveDecode(primitive, &table); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool, len(table))
+	for key := range table {
+		keys[key] = true
+	}
+	return keys, nil
+}
+
+// configKeys returns the toml keys of the fields of a config struct.
+func configKeys(config telegraf.PluginConfig) []string {
+	var keys []string
+	t := reflect.Indirect(reflect.ValueOf(config)).Type()
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := 0; i < t.NumField(); i++ {
+		key := strings.Split(t.Field(i).Tag.Get("toml"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
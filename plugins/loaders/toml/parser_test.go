@@ -0,0 +1,210 @@
+package toml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+type testInputConfig struct {
+	Value int `toml:"value"`
+}
+
+type testInfluxConfig struct {
+	Precision string `toml:"influx_timestamp_precision"`
+}
+
+type testCSVConfig struct {
+	HeaderRowCount int `toml:"csv_header_row_count"`
+}
+
+type testOutputConfig struct {
+	Path string `toml:"path"`
+}
+
+type testJSONConfig struct {
+	TimestampUnits string `toml:"json_timestamp_units"`
+}
+
+type testConfigRegistry struct{}
+
+func (testConfigRegistry) GetPluginConfig(pluginType telegraf.PluginType, name string) (telegraf.PluginConfig, bool) {
+	switch {
+	case pluginType == telegraf.InputType && name == "test":
+		return &testInputConfig{}, true
+	case pluginType == telegraf.ParserType && name == "influx":
+		return &testInfluxConfig{}, true
+	case pluginType == telegraf.ParserType && name == "csv":
+		return &testCSVConfig{}, true
+	case pluginType == telegraf.OutputType && name == "test":
+		return &testOutputConfig{}, true
+	case pluginType == telegraf.SerializerType && name == "influx":
+		return &struct{}{}, true
+	case pluginType == telegraf.SerializerType && name == "json":
+		return &testJSONConfig{}, true
+	}
+	return nil, false
+}
+
+func (r testConfigRegistry) GetConfigParser(format string) (telegraf.ConfigParser, bool) {
+	if format != Name {
+		return nil, false
+	}
+	return NewConfigParser(r), true
+}
+
+func TestParserOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    [][]string
+		wantErr string
+	}{
+		{
+			name: "no parser options",
+			config: `
+[[inputs.test]]
+  value = 1
+`,
+			want: [][]string{nil},
+		},
+		{
+			name: "zero value",
+			config: `
+[[inputs.test]]
+  data_format = "csv"
+  csv_header_row_count = 0
+`,
+			want: [][]string{{"data_format", "csv_header_row_count"}},
+		},
+		{
+			name: "per table",
+			config: `
+[[inputs.test]]
+  data_format = "csv"
+  csv_header_row_count = 1
+
+[[inputs.test]]
+  value = 1
+
+[[inputs.test]]
+  data_format = "influx"
+  influx_timestamp_precision = "1s"
+`,
+			want: [][]string{
+				{"data_format", "csv_header_row_count"},
+				nil,
+				{"data_format", "influx_timestamp_precision"},
+			},
+		},
+		{
+			name: "option without data format",
+			config: `
+[[inputs.test]]
+  influx_timestamp_precision = "1s"
+`,
+			wantErr: "undecoded toml key: inputs.test.influx_timestamp_precision",
+		},
+		{
+			name: "option of other data format",
+			config: `
+[[inputs.test]]
+  csv_header_row_count = 1
+`,
+			wantErr: "undecoded toml key: inputs.test.csv_header_row_count",
+		},
+		{
+			name: "unknown data format",
+			config: `
+[[inputs.test]]
+  data_format = "xml"
+`,
+			wantErr: "unknown data format for input test: xml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := NewParser(testConfigRegistry{}).Parse(strings.NewReader(tt.config))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got [][]string
+			for _, input := range conf.Inputs["test"] {
+				got = append(got, input.ParserOptions)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParserOptions = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSerializerOptions(t *testing.T) {
+	conf, err := NewParser(testConfigRegistry{}).Parse(strings.NewReader(`
+[[outputs.test]]
+  path = "a"
+
+[[outputs.test]]
+  data_format = "json"
+  json_timestamp_units = "1ms"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got [][]string
+	for _, output := range conf.Outputs["test"] {
+		got = append(got, output.SerializerOptions)
+	}
+	want := [][]string{nil, {"data_format", "json_timestamp_units"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SerializerOptions = %q, want %q", got, want)
+	}
+}
+
+func TestParserOptionsDecoded(t *testing.T) {
+	conf, err := NewParser(testConfigRegistry{}).Parse(strings.NewReader(`
+[[inputs.test]]
+  data_format = "csv"
+  csv_header_row_count = 2
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := conf.Inputs["test"][0]
+	if input.Config.DataFormat != "csv" {
+		t.Errorf("DataFormat = %q, want csv", input.Config.DataFormat)
+	}
+	want := &testCSVConfig{HeaderRowCount: 2}
+	if !reflect.DeepEqual(input.ParserConfig, want) {
+		t.Errorf("ParserConfig = %+v, want %+v", input.ParserConfig, want)
+	}
+}
+
+func TestDataFormatUnset(t *testing.T) {
+	conf, err := NewParser(testConfigRegistry{}).Parse(strings.NewReader(`
+[[inputs.test]]
+  value = 1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := conf.Inputs["test"][0]
+	if input.Config.DataFormat != "" {
+		t.Errorf("DataFormat = %q, want unset", input.Config.DataFormat)
+	}
+	if input.ParserConfig != nil {
+		t.Errorf("ParserConfig = %+v, want nil", input.ParserConfig)
+	}
+}
@@ -16,6 +16,8 @@ type Config struct {
 // ExampleOutput is an example output plugin.
 type Example struct {
 	Config Config
+
+	serializer telegraf.Serializer
 }
 
 // Connect connects the output.
@@ -23,7 +25,21 @@ func (p *Example) Connect() error {
 	return nil
 }
 
+// Close closes the output.
+func (p *Example) Close() error {
+	return nil
+}
+
+// Write writes the metrics.
+func (p *Example) Write(metrics []telegraf.Metric) error {
+	return nil
+}
+
+func (p *Example) SetSerializer(serializer telegraf.Serializer) {
+	p.serializer = serializer
+}
+
 // NewExampleOutput creates an ExampleOutput from an ExampleOutputConfig.
 func New(config *Config) ([]telegraf.Output, error) {
-	return []telegraf.Output{&Example{*config}}, nil
+	return []telegraf.Output{&Example{Config: *config}}, nil
 }
@@ -0,0 +1,148 @@
+package file
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+const (
+	Name = "file"
+)
+
+// stdout is the path that writes to standard output.
+const stdout = "stdout"
+
+// Config contains the configuration for the File output.
+type Config struct {
+	// Files are the paths written to, "stdout" writes to standard output.
+	// Defaults to stdout.
+	Files []string `toml:"files"`
+	// UseBatchFormat serializes each batch as a whole rather than each
+	// metric, for formats such as json where they differ.
+	UseBatchFormat bool `toml:"use_batch_format"`
+	// RotationInterval rotates a file once it has been written to for this
+	// long.
+	RotationInterval telegraf.Duration `toml:"rotation_interval"`
+	// RotationMaxSize rotates a file before a write that would make it
+	// larger than this size.
+	RotationMaxSize telegraf.Size `toml:"rotation_max_size"`
+	// RotationMaxArchives is the number of rotated files kept, the oldest
+	// are removed.  Zero keeps all rotated files.
+	RotationMaxArchives int `toml:"rotation_max_archives"`
+}
+
+// File is an output that writes serialized metrics to files or standard
+// output.  Files are appended to, and when rotation is enabled are renamed
+// to archives with the rotation time added to the name.
+type File struct {
+	Config Config
+
+	serializer telegraf.Serializer
+	writers    []io.Writer
+	files      []*rotatingFile
+}
+
+// New creates a File output from a Config.
+func New(config *Config) ([]telegraf.Output, error) {
+	if config.RotationMaxArchives < 0 {
+		return nil, fmt.Errorf("file: rotation_max_archives must not be negative")
+	}
+	return []telegraf.Output{&File{Config: *config}}, nil
+}
+
+func (p *File) SetSerializer(serializer telegraf.Serializer) {
+	p.serializer = serializer
+}
+
+// Connect opens the files, creating any that do not exist.
+func (p *File) Connect() error {
+	paths := p.Config.Files
+	if len(paths) == 0 {
+		paths = []string{stdout}
+	}
+
+	for _, path := range paths {
+		if path == stdout {
+			p.writers = append(p.writers, os.Stdout)
+			continue
+		}
+
+		f := &rotatingFile{
+			path:        path,
+			interval:    p.Config.RotationInterval.Duration,
+			maxSize:     p.Config.RotationMaxSize.Size,
+			maxArchives: p.Config.RotationMaxArchives,
+		}
+		if err := f.open(); err != nil {
+			p.Close()
+			return err
+		}
+		p.writers = append(p.writers, f)
+		p.files = append(p.files, f)
+	}
+	return nil
+}
+
+// Close closes the files.
+func (p *File) Close() error {
+	var err error
+	for _, f := range p.files {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	p.writers = nil
+	p.files = nil
+	return err
+}
+
+// Write serializes the metrics and writes them to every file.  Metrics that
+// fail to serialize are skipped.
+//
+// An error is returned only when no file could be written, as the batch is
+// then retried for all of them.  When only some files fail the error is
+// logged and the batch is lost for those files, rather than being written
+// again to the files that succeeded.
+func (p *File) Write(metrics []telegraf.Metric) error {
+	if len(p.writers) == 0 {
+		return fmt.Errorf("file: not connected")
+	}
+
+	var buf []byte
+	if p.Config.UseBatchFormat {
+		var err error
+		buf, err = p.serializer.SerializeBatch(metrics)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, m := range metrics {
+			b, err := p.serializer.Serialize(m)
+			if err != nil {
+				fmt.Printf("W! [outputs.%s] could not serialize metric: %v\n", Name, err)
+				continue
+			}
+			buf = append(buf, b...)
+		}
+	}
+	if len(buf) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, w := range p.writers {
+		if _, err := w.Write(buf); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 && len(errs) == len(p.writers) {
+		return errs[0]
+	}
+	for _, err := range errs {
+		fmt.Printf("E! [outputs.%s] error writing metrics: %v\n", Name, err)
+	}
+	return nil
+}
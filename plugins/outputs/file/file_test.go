@@ -0,0 +1,173 @@
+package file
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/metric"
+)
+
+// testSerializer serializes a metric as its name on a line, failing for
+// metrics named "bad".
+type testSerializer struct{}
+
+func (testSerializer) Serialize(m telegraf.Metric) ([]byte, error) {
+	if m.Name() == "bad" {
+		return nil, errors.New("bad metric")
+	}
+	return []byte(m.Name() + "\n"), nil
+}
+
+func (testSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	buf := []byte("batch\n")
+	for _, m := range metrics {
+		buf = append(buf, m.Name()+"\n"...)
+	}
+	return buf, nil
+}
+
+type errorWriter struct{}
+
+func (errorWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+type bufferWriter struct {
+	buf []byte
+}
+
+func (w *bufferWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
+
+func testMetrics(t *testing.T, names ...string) []telegraf.Metric {
+	t.Helper()
+	var metrics []telegraf.Metric
+	for _, name := range names {
+		m, err := metric.New(name, nil, map[string]interface{}{"value": 1.0}, time.Unix(0, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		writers []io.Writer
+		metrics []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "each metric",
+			metrics: []string{"a", "bad", "b"},
+			want:    "a\nb\n",
+		},
+		{
+			name:    "batch",
+			config:  Config{UseBatchFormat: true},
+			metrics: []string{"a", "b"},
+			want:    "batch\na\nb\n",
+		},
+		{
+			name:    "some writers fail",
+			writers: []io.Writer{errorWriter{}},
+			metrics: []string{"a"},
+			want:    "a\n",
+		},
+		{
+			name:    "nothing serialized",
+			writers: []io.Writer{errorWriter{}},
+			metrics: []string{"bad"},
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bufferWriter{}
+			p := &File{
+				Config:     tt.config,
+				serializer: testSerializer{},
+				writers:    append(tt.writers, w),
+			}
+			if err := p.Write(testMetrics(t, tt.metrics...)); err != nil {
+				t.Fatal(err)
+			}
+			if string(w.buf) != tt.want {
+				t.Errorf("written = %q, want %q", w.buf, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteAllFail(t *testing.T) {
+	p := &File{
+		serializer: testSerializer{},
+		writers:    []io.Writer{errorWriter{}, errorWriter{}},
+	}
+	if err := p.Write(testMetrics(t, "a")); err == nil {
+		t.Fatal("Write() succeeded with every writer failing")
+	}
+}
+
+func TestWriteNotConnected(t *testing.T) {
+	p := &File{serializer: testSerializer{}}
+	if err := p.Write(testMetrics(t, "a")); err == nil {
+		t.Fatal("Write() succeeded before Connect")
+	}
+}
+
+func TestConnect(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	paths := []string{filepath.Join(dir, "a.out"), filepath.Join(dir, "b.out")}
+	p := &File{Config: Config{Files: paths}, serializer: testSerializer{}}
+	if err := p.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Write(testMetrics(t, "a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range paths {
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != "a\n" {
+			t.Errorf("%s = %q, want %q", path, buf, "a\n")
+		}
+	}
+
+	p = &File{Config: Config{Files: []string{paths[0], filepath.Join(dir, "missing", "c.out")}}}
+	if err := p.Connect(); err == nil {
+		t.Fatal("Connect() succeeded with a missing directory")
+	}
+	if len(p.files) != 0 || len(p.writers) != 0 {
+		t.Errorf("Connect() left %d files open", len(p.files))
+	}
+}
+
+func TestNewNegativeArchives(t *testing.T) {
+	if _, err := New(&Config{RotationMaxArchives: -1}); err == nil {
+		t.Fatal("New() succeeded with negative rotation_max_archives")
+	}
+}
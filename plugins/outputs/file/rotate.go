@@ -0,0 +1,196 @@
+package file
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// archiveLayout is the time format added to the names of rotated files, it
+// sorts in time order.
+const archiveLayout = "2006-01-02T15-04-05.000000000"
+
+// rotatingFile is a file that is rotated by age or size.  Rotated files are
+// named after the file with the rotation time before the extension, such as
+// "metrics.2006-01-02T15-04-05.000000000.out".
+//
+// New files are created under a temporary name and renamed into place, so
+// the path always refers to a complete file.
+type rotatingFile struct {
+	path        string
+	interval    time.Duration
+	maxSize     int64
+	maxArchives int
+
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+// open opens the file for appending, creating it if it does not exist.
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND, 0)
+	if os.IsNotExist(err) {
+		file, err = f.create()
+		if err == nil {
+			err = os.Rename(file.Name(), f.path)
+			if err != nil {
+				file.Close()
+				os.Remove(file.Name())
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.opened = time.Now()
+	return nil
+}
+
+// create creates an empty file under a temporary name in the directory of
+// the file.
+func (f *rotatingFile) create() (*os.File, error) {
+	dir, base := filepath.Split(f.path)
+	if dir == "" {
+		dir = "."
+	}
+	file, err := ioutil.TempFile(dir, "."+base+".*")
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Chmod(0644); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return file, nil
+}
+
+// Write writes to the file, first rotating it if it is due.
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	if f.due(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// due reports if the file should be rotated before writing n bytes.  An
+// empty file is never rotated.
+func (f *rotatingFile) due(n int64) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.interval > 0 && time.Since(f.opened) >= f.interval {
+		return true
+	}
+	return f.maxSize > 0 && f.size+n > f.maxSize
+}
+
+// rotate moves the file to an archive and replaces it with an empty file,
+// then removes the oldest archives.  Failing to remove archives is logged
+// rather than returned, as the rotation itself succeeded.
+func (f *rotatingFile) rotate() error {
+	now := time.Now()
+	file, err := f.create()
+	if err != nil {
+		return err
+	}
+
+	// The file is linked to the archive so that replacing it with the new
+	// file is a single rename, falling back to renaming it where links are
+	// not supported.
+	archive := f.archiveName(now)
+	if err := os.Link(f.path, archive); err != nil {
+		if err := os.Rename(f.path, archive); err != nil {
+			file.Close()
+			os.Remove(file.Name())
+			return err
+		}
+	}
+	if err := os.Rename(file.Name(), f.path); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+
+	f.file.Close()
+	f.file = file
+	f.size = 0
+	f.opened = now
+	if err := f.removeArchives(); err != nil {
+		fmt.Printf("W! [outputs.%s] could not remove archives of %s: %v\n", Name, f.path, err)
+	}
+	return nil
+}
+
+func (f *rotatingFile) archiveName(t time.Time) string {
+	ext := filepath.Ext(f.path)
+	return strings.TrimSuffix(f.path, ext) + "." + t.Format(archiveLayout) + ext
+}
+
+// removeArchives removes all but the newest maxArchives archives.
+func (f *rotatingFile) removeArchives() error {
+	if f.maxArchives <= 0 {
+		return nil
+	}
+
+	dir, base := filepath.Split(f.path)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "."
+	var archives []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) ||
+			len(name) < len(prefix)+len(ext) {
+			continue
+		}
+		stamp := name[len(prefix) : len(name)-len(ext)]
+		if _, err := time.Parse(archiveLayout, stamp); err == nil {
+			archives = append(archives, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(archives)
+
+	for len(archives) > f.maxArchives {
+		if err := os.Remove(archives[0]); err != nil {
+			return err
+		}
+		archives = archives[1:]
+	}
+	return nil
+}
+
+func (f *rotatingFile) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -0,0 +1,245 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestDue(t *testing.T) {
+	tests := []struct {
+		name string
+		file rotatingFile
+		n    int64
+		want bool
+	}{
+		{
+			name: "empty file",
+			file: rotatingFile{maxSize: 1, interval: time.Nanosecond},
+			n:    10,
+			want: false,
+		},
+		{
+			name: "no rotation",
+			file: rotatingFile{size: 100},
+			n:    10,
+			want: false,
+		},
+		{
+			name: "within size",
+			file: rotatingFile{size: 5, maxSize: 10},
+			n:    5,
+			want: false,
+		},
+		{
+			name: "exceeds size",
+			file: rotatingFile{size: 5, maxSize: 10},
+			n:    6,
+			want: true,
+		},
+		{
+			name: "interval elapsed",
+			file: rotatingFile{size: 1, interval: time.Minute, opened: time.Now().Add(-time.Hour)},
+			want: true,
+		},
+		{
+			name: "interval not elapsed",
+			file: rotatingFile{size: 1, interval: time.Hour, opened: time.Now()},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if due := tt.file.due(tt.n); due != tt.want {
+				t.Errorf("due(%d) = %v, want %v", tt.n, due, tt.want)
+			}
+		})
+	}
+}
+
+func TestArchiveName(t *testing.T) {
+	tm := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/tmp/metrics.out", "/tmp/metrics.2020-01-02T03-04-05.000000006.out"},
+		{"metrics", "metrics.2020-01-02T03-04-05.000000006"},
+		{"/tmp/a.b/metrics.log", "/tmp/a.b/metrics.2020-01-02T03-04-05.000000006.log"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			f := &rotatingFile{path: tt.path}
+			if name := f.archiveName(tm); name != tt.want {
+				t.Errorf("archiveName() = %s, want %s", name, tt.want)
+			}
+		})
+	}
+}
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func readDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestOpen(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "metrics.out")
+
+	f := &rotatingFile{path: path}
+	if err := f.open(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	// Reopening appends to the existing file.
+	f = &rotatingFile{path: path}
+	if err := f.open(); err != nil {
+		t.Fatal(err)
+	}
+	if f.size != 3 {
+		t.Errorf("size = %d, want 3", f.size)
+	}
+	if _, err := f.Write([]byte("def")); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "abcdef" {
+		t.Errorf("contents = %q, want %q", buf, "abcdef")
+	}
+	if names := readDir(t, dir); len(names) != 1 {
+		t.Errorf("files = %v, want only metrics.out", names)
+	}
+
+	f = &rotatingFile{path: filepath.Join(dir, "missing", "metrics.out")}
+	if err := f.open(); err == nil {
+		t.Error("open() in a missing directory succeeded")
+	}
+}
+
+func TestRotateBySize(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "metrics.out")
+
+	f := &rotatingFile{path: path, maxSize: 4, maxArchives: 2}
+	if err := f.open(); err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	for _, s := range []string{"aaa", "bbb", "ccc", "ddd"} {
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "ddd" {
+		t.Errorf("contents = %q, want %q", buf, "ddd")
+	}
+
+	names := readDir(t, dir)
+	if len(names) != 3 {
+		t.Fatalf("files = %v, want metrics.out and 2 archives", names)
+	}
+	for i, want := range []string{"bbb", "ccc"} {
+		buf, err := ioutil.ReadFile(filepath.Join(dir, names[i]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != want {
+			t.Errorf("archive %s = %q, want %q", names[i], buf, want)
+		}
+	}
+}
+
+func TestRemoveArchives(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	files := []string{
+		"metrics.out",
+		"metrics.2020-01-01T00-00-00.000000000.out",
+		"metrics.2020-01-02T00-00-00.000000000.out",
+		"metrics.2020-01-03T00-00-00.000000000.out",
+		"metrics.other.out",
+		"metrics.2020-01-01T00-00-00.000000000.log",
+		"other.2020-01-01T00-00-00.000000000.out",
+	}
+	for _, name := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		maxArchives int
+		removed     []string
+	}{
+		{maxArchives: 0},
+		{maxArchives: 3},
+		{maxArchives: 1, removed: files[1:3]},
+	}
+	for _, tt := range tests {
+		f := &rotatingFile{path: filepath.Join(dir, "metrics.out"), maxArchives: tt.maxArchives}
+		if err := f.removeArchives(); err != nil {
+			t.Fatal(err)
+		}
+
+		var want []string
+		for _, name := range files {
+			kept := true
+			for _, removed := range tt.removed {
+				kept = kept && name != removed
+			}
+			if kept {
+				want = append(want, name)
+			}
+		}
+		sort.Strings(want)
+
+		names := readDir(t, dir)
+		if len(names) != len(want) {
+			t.Fatalf("maxArchives %d: files = %v, want %v", tt.maxArchives, names, want)
+		}
+		for i := range names {
+			if names[i] != want[i] {
+				t.Fatalf("maxArchives %d: files = %v, want %v", tt.maxArchives, names, want)
+			}
+		}
+	}
+}
@@ -2,8 +2,10 @@ package outputs
 
 import (
 	"github.com/influxdata/tgconfig/plugins/outputs/example"
+	"github.com/influxdata/tgconfig/plugins/outputs/file"
 )
 
 var Outputs = map[string]interface{}{
 	example.Name: example.New,
+	file.Name:    file.New,
 }
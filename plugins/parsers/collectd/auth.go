@@ -0,0 +1,79 @@
+package collectd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// authFile is a file of "user: password" lines as used by collectd.  It is
+// read on first use and reread whenever its modification time changes.
+type authFile struct {
+	path string
+
+	mu        sync.Mutex
+	modTime   time.Time
+	passwords map[string]string
+}
+
+func newAuthFile(path string) *authFile {
+	return &authFile{path: path}
+}
+
+// password returns the password of the user.
+func (a *authFile) password(user string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	err := a.update()
+	if err != nil {
+		return "", err
+	}
+
+	password, ok := a.passwords[user]
+	if !ok {
+		return "", fmt.Errorf("unknown user %s", user)
+	}
+	return password, nil
+}
+
+// update rereads the file if it has changed.
+func (a *authFile) update() error {
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return err
+	}
+	if a.passwords != nil && info.ModTime().Equal(a.modTime) {
+		return nil
+	}
+
+	f, err := os.Open(a.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	passwords := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		passwords[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	a.passwords = passwords
+	a.modTime = info.ModTime()
+	return nil
+}
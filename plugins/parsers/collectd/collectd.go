@@ -1,25 +1,159 @@
 package collectd
 
 import (
+	"fmt"
+	"time"
+
 	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/metric"
 )
 
 const (
 	Name = "collectd"
 )
 
+// SecurityLevel is the minimum protection a packet must have to be
+// accepted.
+type SecurityLevel string
+
+const (
+	// SecurityNone accepts all packets; signed packets are verified when
+	// the user is known.
+	SecurityNone SecurityLevel = "none"
+	// SecuritySign requires packets to be signed or encrypted.
+	SecuritySign SecurityLevel = "sign"
+	// SecurityEncrypt requires packets to be encrypted.
+	SecurityEncrypt SecurityLevel = "encrypt"
+)
+
+// Config contains the configuration for the Collectd parser.
 type Config struct {
+	// AuthFile contains the "user: password" pairs used to verify signed
+	// packets and to decrypt encrypted packets.  It is reread when it
+	// changes.
 	AuthFile string `toml:"collectd_auth_file"`
+	// SecurityLevel is one of "none", "sign" or "encrypt"; defaults to
+	// "none".
+	SecurityLevel SecurityLevel `toml:"collectd_security_level"`
+	// TypesDB lists the types.db files used to name the values of each
+	// type.
+	TypesDB []string `toml:"collectd_typesdb"`
+	// ParseMultiValue is "split" to create a metric for each value of a
+	// type, or "join" to create a single metric with a field for each
+	// value; defaults to "split".
+	ParseMultiValue string `toml:"collectd_parse_multivalue"`
 }
 
+// Collectd is a parser for the collectd binary network protocol.
 type Collectd struct {
-	AuthFile string
+	AuthFile      string
+	SecurityLevel SecurityLevel
+	MultiValue    string
+
+	auth  *authFile
+	types typesDB
 }
 
-func (i *Collectd) Parse(buf []byte) ([]telegraf.Metric, error) {
-	return []telegraf.Metric{}, nil
+func (p *Collectd) Parse(buf []byte) ([]telegraf.Metric, error) {
+	valueLists, err := parsePacket(buf, p.SecurityLevel, p.auth)
+	if err != nil {
+		return nil, fmt.Errorf("collectd: %v", err)
+	}
+
+	metrics := make([]telegraf.Metric, 0)
+	for _, vl := range valueLists {
+		m, err := p.makeMetrics(vl)
+		if err != nil {
+			return nil, fmt.Errorf("collectd: %v", err)
+		}
+		metrics = append(metrics, m...)
+	}
+	return metrics, nil
 }
 
 func New(config *Config) (telegraf.Parser, error) {
-	return &Collectd{}, nil
+	level := config.SecurityLevel
+	switch level {
+	case "":
+		level = SecurityNone
+	case SecurityNone, SecuritySign, SecurityEncrypt:
+	default:
+		return nil, fmt.Errorf("collectd: invalid security level: %s", level)
+	}
+
+	multiValue := config.ParseMultiValue
+	switch multiValue {
+	case "":
+		multiValue = "split"
+	case "split", "join":
+	default:
+		return nil, fmt.Errorf("collectd: invalid parse multivalue: %s", multiValue)
+	}
+
+	types := make(typesDB)
+	for _, path := range config.TypesDB {
+		if err := types.load(path); err != nil {
+			return nil, fmt.Errorf("collectd: %v", err)
+		}
+	}
+
+	p := &Collectd{
+		AuthFile:      config.AuthFile,
+		SecurityLevel: level,
+		MultiValue:    multiValue,
+		types:         types,
+	}
+	if config.AuthFile != "" {
+		p.auth = newAuthFile(config.AuthFile)
+	}
+	return p, nil
+}
+
+// makeMetrics converts a value list into metrics.  Split metrics are named
+// after the plugin and the value, such as "cpu_value", with a single field
+// named "value".  A joined metric is named after the plugin, with a field
+// for each value.
+func (p *Collectd) makeMetrics(vl *valueList) ([]telegraf.Metric, error) {
+	tm := vl.Time
+	if tm.IsZero() {
+		tm = time.Now()
+	}
+
+	tags := make(map[string]string)
+	if vl.Host != "" {
+		tags["host"] = vl.Host
+	}
+	if vl.PluginInstance != "" {
+		tags["instance"] = vl.PluginInstance
+	}
+	if vl.Type != "" {
+		tags["type"] = vl.Type
+	}
+	if vl.TypeInstance != "" {
+		tags["type_instance"] = vl.TypeInstance
+	}
+
+	if p.MultiValue == "join" {
+		fields := make(map[string]interface{}, len(vl.Values))
+		for i, value := range vl.Values {
+			fields[p.types.dsName(vl.Type, i, len(vl.Values))] = value
+		}
+		m, err := metric.New(vl.Plugin, tags, fields, tm)
+		if err != nil {
+			return nil, err
+		}
+		return []telegraf.Metric{m}, nil
+	}
+
+	metrics := make([]telegraf.Metric, 0, len(vl.Values))
+	for i, value := range vl.Values {
+		name := vl.Plugin + "_" + p.types.dsName(vl.Type, i, len(vl.Values))
+		fields := map[string]interface{}{"value": value}
+		m, err := metric.New(name, tags, fields, tm)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
 }
@@ -0,0 +1,301 @@
+package collectd
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func part(typ uint16, data []byte) []byte {
+	b := make([]byte, headerSize, headerSize+len(data))
+	binary.BigEndian.PutUint16(b[0:2], typ)
+	binary.BigEndian.PutUint16(b[2:4], uint16(headerSize+len(data)))
+	return append(b, data...)
+}
+
+func stringPart(typ uint16, s string) []byte {
+	return part(typ, append([]byte(s), 0))
+}
+
+func numberPart(typ uint16, n uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, n)
+	return part(typ, b)
+}
+
+// gaugesPart is a values part of gauges.
+func gaugesPart(values ...float64) []byte {
+	b := make([]byte, 2, 2+9*len(values))
+	binary.BigEndian.PutUint16(b, uint16(len(values)))
+	for range values {
+		b = append(b, dsGauge)
+	}
+	for _, v := range values {
+		raw := make([]byte, 8)
+		binary.LittleEndian.PutUint64(raw, math.Float64bits(v))
+		b = append(b, raw...)
+	}
+	return part(partValues, b)
+}
+
+func testPacket(values ...float64) []byte {
+	var b []byte
+	b = append(b, stringPart(partHost, "web01")...)
+	b = append(b, numberPart(partTime, 1600000000)...)
+	b = append(b, stringPart(partPlugin, "interface")...)
+	b = append(b, stringPart(partPluginInstance, "eth0")...)
+	b = append(b, stringPart(partType, "if_octets")...)
+	b = append(b, gaugesPart(values...)...)
+	return b
+}
+
+func sign(user, password string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(password))
+	mac.Write([]byte(user))
+	mac.Write(payload)
+	b := part(partSignature, append(mac.Sum(nil), user...))
+	return append(b, payload...)
+}
+
+func encrypt(t *testing.T, user, password string, payload []byte) []byte {
+	t.Helper()
+	key := sha256.Sum256([]byte(password))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	iv := bytes.Repeat([]byte{7}, ivSize)
+	hash := sha1.Sum(payload)
+	plaintext := append(hash[:], payload...)
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewOFB(block, iv).XORKeyStream(ciphertext, plaintext)
+
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, uint16(len(user)))
+	b = append(b, user...)
+	b = append(b, iv...)
+	b = append(b, ciphertext...)
+	return part(partEncryption, b)
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collectd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	authFile := writeFile(t, dir, "auth", "# users\nalice: secret\nbob:hunter2\n")
+	typesDB := writeFile(t, dir, "types.db", "if_octets  rx:DERIVE:0:U, tx:DERIVE:0:U\n")
+
+	type result struct {
+		name   string
+		fields map[string]interface{}
+	}
+	tests := []struct {
+		name    string
+		config  Config
+		packet  []byte
+		want    []result
+		wantErr string
+	}{
+		{
+			name:   "split",
+			packet: testPacket(1, 2),
+			want: []result{
+				{"interface_0", map[string]interface{}{"value": 1.0}},
+				{"interface_1", map[string]interface{}{"value": 2.0}},
+			},
+		},
+		{
+			name:   "split with types.db",
+			config: Config{TypesDB: []string{typesDB}},
+			packet: testPacket(1, 2),
+			want: []result{
+				{"interface_rx", map[string]interface{}{"value": 1.0}},
+				{"interface_tx", map[string]interface{}{"value": 2.0}},
+			},
+		},
+		{
+			name:   "join",
+			config: Config{TypesDB: []string{typesDB}, ParseMultiValue: "join"},
+			packet: testPacket(1, 2),
+			want: []result{
+				{"interface", map[string]interface{}{"rx": 1.0, "tx": 2.0}},
+			},
+		},
+		{
+			name:   "signed",
+			config: Config{AuthFile: authFile, SecurityLevel: SecuritySign},
+			packet: sign("alice", "secret", testPacket(1)),
+			want: []result{
+				{"interface_value", map[string]interface{}{"value": 1.0}},
+			},
+		},
+		{
+			name:    "signed with wrong password",
+			config:  Config{AuthFile: authFile, SecurityLevel: SecuritySign},
+			packet:  sign("alice", "wrong", testPacket(1)),
+			wantErr: "invalid signature from user alice",
+		},
+		{
+			name:   "signed by unknown user without security",
+			config: Config{AuthFile: authFile},
+			packet: sign("mallory", "x", testPacket(1)),
+			want: []result{
+				{"interface_value", map[string]interface{}{"value": 1.0}},
+			},
+		},
+		{
+			name:    "signed by unknown user",
+			config:  Config{AuthFile: authFile, SecurityLevel: SecuritySign},
+			packet:  sign("mallory", "x", testPacket(1)),
+			wantErr: "unknown user mallory",
+		},
+		{
+			name:    "unsigned",
+			config:  Config{AuthFile: authFile, SecurityLevel: SecuritySign},
+			packet:  testPacket(1),
+			wantErr: "does not meet security level sign",
+		},
+		{
+			name:   "encrypted",
+			config: Config{AuthFile: authFile, SecurityLevel: SecurityEncrypt},
+			packet: encrypt(t, "bob", "hunter2", testPacket(1)),
+			want: []result{
+				{"interface_value", map[string]interface{}{"value": 1.0}},
+			},
+		},
+		{
+			name:   "encrypted meets sign",
+			config: Config{AuthFile: authFile, SecurityLevel: SecuritySign},
+			packet: encrypt(t, "bob", "hunter2", testPacket(1)),
+			want: []result{
+				{"interface_value", map[string]interface{}{"value": 1.0}},
+			},
+		},
+		{
+			name:    "encrypted with wrong password",
+			config:  Config{AuthFile: authFile, SecurityLevel: SecurityEncrypt},
+			packet:  encrypt(t, "bob", "wrong", testPacket(1)),
+			wantErr: "unable to decrypt packet from user bob",
+		},
+		{
+			name:    "signed does not meet encrypt",
+			config:  Config{AuthFile: authFile, SecurityLevel: SecurityEncrypt},
+			packet:  sign("alice", "secret", testPacket(1)),
+			wantErr: "does not meet security level encrypt",
+		},
+		{
+			name:    "truncated",
+			packet:  testPacket(1)[:14],
+			wantErr: "invalid length",
+		},
+		{
+			name:    "unterminated string",
+			packet:  part(partHost, []byte("web01")),
+			wantErr: "not null terminated",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(&tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			metrics, err := p.Parse(tt.packet)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]result, 0, len(metrics))
+			for _, m := range metrics {
+				got = append(got, result{m.Name(), m.Fields()})
+				wantTags := map[string]string{
+					"host": "web01", "instance": "eth0", "type": "if_octets",
+				}
+				if !reflect.DeepEqual(m.Tags(), wantTags) {
+					t.Errorf("tags = %v, want %v", m.Tags(), wantTags)
+				}
+				if !m.Time().Equal(time.Unix(1600000000, 0)) {
+					t.Errorf("time = %v", m.Time())
+				}
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i].name < got[j].name })
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("metrics = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseValueTypes(t *testing.T) {
+	data := []byte{0, 3, dsCounter, dsDerive, dsAbsolute}
+	for _, v := range []uint64{10, uint64(math.MaxUint64), 30} {
+		raw := make([]byte, 8)
+		binary.BigEndian.PutUint64(raw, v)
+		data = append(data, raw...)
+	}
+	values, err := parseValues(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{10, -1, 30}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("parseValues() = %v, want %v", values, want)
+	}
+}
+
+func TestHRTime(t *testing.T) {
+	hr := uint64(1600000000)<<30 | 1<<29
+	want := time.Unix(1600000000, 500000000)
+	if got := hrTime(hr); !got.Equal(want) {
+		t.Errorf("hrTime() = %v, want %v", got, want)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "security level", config: Config{SecurityLevel: "strict"}},
+		{name: "multivalue", config: Config{ParseMultiValue: "merge"}},
+		{name: "missing types.db", config: Config{TypesDB: []string{"/nonexistent/types.db"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(&tt.config); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
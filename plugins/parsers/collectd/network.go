@@ -0,0 +1,282 @@
+package collectd
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Part types of the collectd network protocol.
+const (
+	partHost           = 0x0000
+	partTime           = 0x0001
+	partPlugin         = 0x0002
+	partPluginInstance = 0x0003
+	partType           = 0x0004
+	partTypeInstance   = 0x0005
+	partValues         = 0x0006
+	partInterval       = 0x0007
+	partTimeHR         = 0x0008
+	partIntervalHR     = 0x0009
+	partSignature      = 0x0200
+	partEncryption     = 0x0210
+)
+
+// Data source types of a values part.
+const (
+	dsCounter  = 0
+	dsGauge    = 1
+	dsDerive   = 2
+	dsAbsolute = 3
+)
+
+const (
+	headerSize = 4
+	hmacSize   = sha256.Size
+	ivSize     = aes.BlockSize
+)
+
+// valueList is a set of values sharing an identifier and time.
+type valueList struct {
+	Host           string
+	Plugin         string
+	PluginInstance string
+	Type           string
+	TypeInstance   string
+	Time           time.Time
+	Values         []float64
+}
+
+// parsePacket parses the value lists of a packet, verifying or decrypting
+// it as required by the security level.
+func parsePacket(buf []byte, level SecurityLevel, auth *authFile) ([]*valueList, error) {
+	p := &packetParser{level: level, auth: auth}
+	err := p.parse(buf, level)
+	if err != nil {
+		return nil, err
+	}
+	return p.valueLists, nil
+}
+
+type packetParser struct {
+	level SecurityLevel
+	auth  *authFile
+
+	// state holds the identifier and time set by the preceding parts, they
+	// apply to all following value lists.
+	state      valueList
+	valueLists []*valueList
+}
+
+// parse parses the parts of buf.  The required level is lowered once the
+// remainder of the buffer has been verified or decrypted.
+func (p *packetParser) parse(buf []byte, required SecurityLevel) error {
+	for len(buf) > 0 {
+		if len(buf) < headerSize {
+			return errors.New("truncated part header")
+		}
+		typ := binary.BigEndian.Uint16(buf[0:2])
+		length := int(binary.BigEndian.Uint16(buf[2:4]))
+		if length < headerSize || length > len(buf) {
+			return fmt.Errorf("invalid length %d for part type 0x%04x", length, typ)
+		}
+		part := buf[headerSize:length]
+
+		switch typ {
+		case partSignature:
+			err := p.verify(part, buf[length:])
+			if err != nil {
+				return err
+			}
+			if required == SecuritySign {
+				required = SecurityNone
+			}
+		case partEncryption:
+			plaintext, err := p.decrypt(part)
+			if err != nil {
+				return err
+			}
+			err = p.parse(plaintext, SecurityNone)
+			if err != nil {
+				return err
+			}
+		default:
+			if required != SecurityNone {
+				return fmt.Errorf("packet does not meet security level %s", p.level)
+			}
+			err := p.parsePart(typ, part)
+			if err != nil {
+				return err
+			}
+		}
+
+		buf = buf[length:]
+	}
+	return nil
+}
+
+func (p *packetParser) parsePart(typ uint16, part []byte) error {
+	var err error
+	switch typ {
+	case partHost:
+		p.state.Host, err = parseString(part)
+	case partPlugin:
+		p.state.Plugin, err = parseString(part)
+	case partPluginInstance:
+		p.state.PluginInstance, err = parseString(part)
+	case partType:
+		p.state.Type, err = parseString(part)
+	case partTypeInstance:
+		p.state.TypeInstance, err = parseString(part)
+	case partTime:
+		var seconds uint64
+		seconds, err = parseNumber(part)
+		p.state.Time = time.Unix(int64(seconds), 0)
+	case partTimeHR:
+		var hr uint64
+		hr, err = parseNumber(part)
+		p.state.Time = hrTime(hr)
+	case partValues:
+		var values []float64
+		values, err = parseValues(part)
+		if err == nil {
+			vl := p.state
+			vl.Values = values
+			p.valueLists = append(p.valueLists, &vl)
+		}
+	default:
+		// Intervals, notifications and unknown parts are ignored.
+	}
+	return err
+}
+
+// verify checks the signature part against the remainder of the packet.
+func (p *packetParser) verify(part []byte, rest []byte) error {
+	if len(part) < hmacSize {
+		return errors.New("truncated signature part")
+	}
+	signature := part[:hmacSize]
+	user := string(part[hmacSize:])
+
+	password, err := p.password(user)
+	if err != nil {
+		// Signed packets from unknown users are still usable when no
+		// security is required.
+		if p.level == SecurityNone {
+			return nil
+		}
+		return err
+	}
+
+	mac := hmac.New(sha256.New, []byte(password))
+	mac.Write([]byte(user))
+	mac.Write(rest)
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		return fmt.Errorf("invalid signature from user %s", user)
+	}
+	return nil
+}
+
+// decrypt returns the plaintext of an encryption part.  The part holds the
+// user, the IV and the AES-256-OFB encrypted SHA-1 hash of the plaintext
+// followed by the plaintext.  The key is the SHA-256 hash of the password.
+func (p *packetParser) decrypt(part []byte) ([]byte, error) {
+	if len(part) < 2 {
+		return nil, errors.New("truncated encryption part")
+	}
+	userLen := int(binary.BigEndian.Uint16(part[0:2]))
+	if len(part) < 2+userLen+ivSize+sha1.Size {
+		return nil, errors.New("truncated encryption part")
+	}
+	user := string(part[2 : 2+userLen])
+	iv := part[2+userLen : 2+userLen+ivSize]
+	ciphertext := part[2+userLen+ivSize:]
+
+	password, err := p.password(user)
+	if err != nil {
+		return nil, err
+	}
+
+	key := sha256.Sum256([]byte(password))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewOFB(block, iv).XORKeyStream(plaintext, ciphertext)
+
+	hash := sha1.Sum(plaintext[sha1.Size:])
+	if !bytes.Equal(hash[:], plaintext[:sha1.Size]) {
+		return nil, fmt.Errorf("unable to decrypt packet from user %s", user)
+	}
+	return plaintext[sha1.Size:], nil
+}
+
+func (p *packetParser) password(user string) (string, error) {
+	if p.auth == nil {
+		return "", fmt.Errorf("no auth file for user %s", user)
+	}
+	return p.auth.password(user)
+}
+
+// parseString parses a null terminated string.
+func parseString(part []byte) (string, error) {
+	if len(part) == 0 || part[len(part)-1] != 0 {
+		return "", errors.New("string part is not null terminated")
+	}
+	return string(part[:len(part)-1]), nil
+}
+
+func parseNumber(part []byte) (uint64, error) {
+	if len(part) != 8 {
+		return 0, errors.New("invalid numeric part")
+	}
+	return binary.BigEndian.Uint64(part), nil
+}
+
+// parseValues parses a values part: the number of values, the data source
+// type of each value and then the values.  Gauges are little endian
+// doubles, the other types are big endian integers.
+func parseValues(part []byte) ([]float64, error) {
+	if len(part) < 2 {
+		return nil, errors.New("truncated values part")
+	}
+	n := int(binary.BigEndian.Uint16(part[0:2]))
+	if len(part) != 2+n*9 {
+		return nil, errors.New("invalid values part")
+	}
+	types := part[2 : 2+n]
+	data := part[2+n:]
+
+	values := make([]float64, n)
+	for i := range values {
+		raw := data[i*8 : i*8+8]
+		switch types[i] {
+		case dsGauge:
+			values[i] = math.Float64frombits(binary.LittleEndian.Uint64(raw))
+		case dsDerive:
+			values[i] = float64(int64(binary.BigEndian.Uint64(raw)))
+		case dsCounter, dsAbsolute:
+			values[i] = float64(binary.BigEndian.Uint64(raw))
+		default:
+			return nil, fmt.Errorf("unknown data source type %d", types[i])
+		}
+	}
+	return values, nil
+}
+
+// hrTime converts a high resolution time, in units of 2^-30 seconds.
+func hrTime(hr uint64) time.Time {
+	seconds := hr >> 30
+	fraction := hr & (1<<30 - 1)
+	nanos := (fraction * 1e9) >> 30
+	return time.Unix(int64(seconds), int64(nanos))
+}
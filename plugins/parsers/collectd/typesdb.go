@@ -0,0 +1,62 @@
+package collectd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// typesDB maps each collectd type to the names of its data sources.
+type typesDB map[string][]string
+
+// load adds the types of a types.db file.  Each line is a type followed by
+// its data sources, such as:
+//
+//	if_octets  rx:DERIVE:0:U, tx:DERIVE:0:U
+func (db typesDB) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	lineno := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return fmt.Errorf("%s:%d: type has no data sources", path, lineno)
+		}
+
+		var names []string
+		for _, ds := range strings.Split(strings.Join(fields[1:], ""), ",") {
+			parts := strings.Split(ds, ":")
+			if len(parts) != 4 {
+				return fmt.Errorf("%s:%d: invalid data source %q", path, lineno, ds)
+			}
+			names = append(names, parts[0])
+		}
+		db[fields[0]] = names
+	}
+	return scanner.Err()
+}
+
+// dsName returns the name of the i-th of n values of a type.  Types not in
+// the database use "value" for a single value and the index otherwise.
+func (db typesDB) dsName(typ string, i int, n int) string {
+	if names, ok := db[typ]; ok && len(names) == n {
+		return names[i]
+	}
+	if n == 1 {
+		return "value"
+	}
+	return strconv.Itoa(i)
+}
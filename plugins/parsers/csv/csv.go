@@ -0,0 +1,316 @@
+package csv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/internal/timestamp"
+	"github.com/influxdata/tgconfig/metric"
+)
+
+const (
+	Name = "csv"
+)
+
+const defaultMetricName = "csv"
+
+// Config contains the configuration for the CSV parser.
+type Config struct {
+	// HeaderRowCount is the number of header rows, the column names are
+	// the header rows concatenated.  When neither HeaderRowCount nor
+	// ColumnNames is set the header is detected: the first row is the
+	// header unless it contains a number or boolean, in which case the
+	// columns are named "column1", "column2" and so on.
+	HeaderRowCount int `toml:"csv_header_row_count"`
+	// ColumnNames names the columns, overriding the header rows.  Columns
+	// without a name are skipped.
+	ColumnNames []string `toml:"csv_column_names"`
+	// ColumnTypes are the types of the columns, each "int", "float",
+	// "bool" or "string".  When unset the type of each value is detected.
+	ColumnTypes []string `toml:"csv_column_types"`
+	// TagColumns are the columns that become tags.
+	TagColumns []string `toml:"csv_tag_columns"`
+	// MeasurementColumn is the column used as the measurement name,
+	// defaults to "csv".
+	MeasurementColumn string `toml:"csv_measurement_column"`
+	// TimestampColumn is the column containing the timestamp, when unset
+	// the time of parsing is used.
+	TimestampColumn string `toml:"csv_timestamp_column"`
+	// TimestampFormat is "unix", "unix_ms", "unix_us", "unix_ns" or a Go
+	// time layout; required with TimestampColumn.
+	TimestampFormat string `toml:"csv_timestamp_format"`
+	// Timezone is the location used for layouts without a zone, defaults to
+	// UTC.
+	Timezone string `toml:"csv_timezone"`
+	// Comment is the character that starts a comment line.
+	Comment string `toml:"csv_comment"`
+	// SkipRows is the number of lines skipped before the header.
+	SkipRows int `toml:"csv_skip_rows"`
+	// SkipColumns is the number of leading columns skipped.
+	SkipColumns int `toml:"csv_skip_columns"`
+	// Delimiter separates the columns, defaults to ",".
+	Delimiter string `toml:"csv_delimiter"`
+	// TrimSpace removes leading and trailing space from values.
+	TrimSpace bool `toml:"csv_trim_space"`
+}
+
+// CSV is a parser for comma separated values, each row becomes a metric.
+type CSV struct {
+	Config Config
+
+	delimiter rune
+	comment   rune
+	location  *time.Location
+	tags      map[string]bool
+}
+
+func New(config *Config) (telegraf.Parser, error) {
+	p := &CSV{
+		Config:    *config,
+		delimiter: ',',
+		tags:      make(map[string]bool),
+	}
+
+	if config.HeaderRowCount < 0 {
+		return nil, fmt.Errorf("csv: csv_header_row_count must not be negative")
+	}
+	if len(config.ColumnNames) > 0 && len(config.ColumnTypes) > 0 &&
+		len(config.ColumnNames) != len(config.ColumnTypes) {
+		return nil, fmt.Errorf("csv: csv_column_names and csv_column_types must be the same length")
+	}
+	for _, typ := range config.ColumnTypes {
+		switch typ {
+		case "int", "float", "bool", "string":
+		default:
+			return nil, fmt.Errorf("csv: invalid column type: %s", typ)
+		}
+	}
+	if config.TimestampColumn != "" && config.TimestampFormat == "" {
+		return nil, fmt.Errorf("csv: csv_timestamp_format is required with csv_timestamp_column")
+	}
+
+	var err error
+	if p.delimiter, err = toRune("csv_delimiter", config.Delimiter, ','); err != nil {
+		return nil, err
+	}
+	if p.comment, err = toRune("csv_comment", config.Comment, 0); err != nil {
+		return nil, err
+	}
+	if p.comment == p.delimiter {
+		return nil, fmt.Errorf("csv: csv_comment must differ from csv_delimiter")
+	}
+
+	if config.Timezone != "" {
+		p.location, err = time.LoadLocation(config.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("csv: %v", err)
+		}
+	}
+
+	for _, column := range config.TagColumns {
+		p.tags[column] = true
+	}
+	return p, nil
+}
+
+// toRune returns the single character of an option, or def when unset.
+func toRune(option string, s string, def rune) (rune, error) {
+	if s == "" {
+		return def, nil
+	}
+	if utf8.RuneCountInString(s) != 1 {
+		return 0, fmt.Errorf("csv: %s must be a single character", option)
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return r, nil
+}
+
+func (p *CSV) Parse(buf []byte) ([]telegraf.Metric, error) {
+	reader := bufio.NewReader(bytes.NewReader(buf))
+	for i := 0; i < p.Config.SkipRows; i++ {
+		_, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return []telegraf.Metric{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r := csv.NewReader(reader)
+	r.Comma = p.delimiter
+	r.Comment = p.comment
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = p.Config.TrimSpace
+
+	var header []string
+	for i := 0; i < p.Config.HeaderRowCount; i++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			return []telegraf.Metric{}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("csv: %v", err)
+		}
+		record = p.skipColumns(record)
+		for j, name := range record {
+			if j < len(header) {
+				header[j] += strings.TrimSpace(name)
+			} else {
+				header = append(header, strings.TrimSpace(name))
+			}
+		}
+	}
+
+	columns := header
+	if len(p.Config.ColumnNames) > 0 {
+		columns = p.Config.ColumnNames
+	}
+
+	// Without a header or column names the first record is read ahead to
+	// detect if it is the header.
+	var first []string
+	if p.Config.HeaderRowCount == 0 && len(p.Config.ColumnNames) == 0 {
+		record, err := r.Read()
+		if err == io.EOF {
+			return []telegraf.Metric{}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("csv: %v", err)
+		}
+		record = p.skipColumns(record)
+		if isHeader(record) {
+			for _, name := range record {
+				columns = append(columns, strings.TrimSpace(name))
+			}
+		} else {
+			for i := range record {
+				columns = append(columns, fmt.Sprintf("column%d", i+1))
+			}
+			first = record
+		}
+	}
+
+	now := time.Now()
+	metrics := make([]telegraf.Metric, 0)
+	for n := 1; ; n++ {
+		record := first
+		first = nil
+		if record == nil {
+			var err error
+			record, err = r.Read()
+			if err == io.EOF {
+				return metrics, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("csv: %v", err)
+			}
+			record = p.skipColumns(record)
+		}
+
+		m, err := p.makeMetric(columns, record, now)
+		if err != nil {
+			return nil, fmt.Errorf("csv: record %d: %v", n, err)
+		}
+		metrics = append(metrics, m)
+	}
+}
+
+// isHeader reports if a record is a header, that is none of its values are
+// numbers or booleans.
+func isHeader(record []string) bool {
+	for _, value := range record {
+		v, _ := convert(strings.TrimSpace(value), "")
+		if _, ok := v.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *CSV) skipColumns(record []string) []string {
+	if p.Config.SkipColumns >= len(record) {
+		return nil
+	}
+	return record[p.Config.SkipColumns:]
+}
+
+func (p *CSV) makeMetric(columns []string, record []string, now time.Time) (telegraf.Metric, error) {
+	name := defaultMetricName
+	tm := now
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+
+	for i, value := range record {
+		if i >= len(columns) || columns[i] == "" {
+			continue
+		}
+		column := columns[i]
+		if p.Config.TrimSpace {
+			value = strings.TrimSpace(value)
+		}
+
+		switch {
+		case column == p.Config.MeasurementColumn:
+			name = value
+		case column == p.Config.TimestampColumn:
+			t, err := timestamp.Parse(p.Config.TimestampFormat, value, p.location)
+			if err != nil {
+				return nil, err
+			}
+			tm = t
+		case p.tags[column]:
+			tags[column] = value
+		default:
+			if value == "" {
+				continue
+			}
+			typ := ""
+			if i < len(p.Config.ColumnTypes) {
+				typ = p.Config.ColumnTypes[i]
+			}
+			v, err := convert(value, typ)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %v", column, err)
+			}
+			fields[column] = v
+		}
+	}
+
+	return metric.New(name, tags, fields, tm)
+}
+
+// convert converts a value to the column type, or when the type is unset
+// to the first of int, float and bool that it is valid for, falling back to
+// string.
+func convert(value string, typ string) (interface{}, error) {
+	switch typ {
+	case "int":
+		return strconv.ParseInt(value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(value, 64)
+	case "bool":
+		return strconv.ParseBool(value)
+	case "string":
+		return value, nil
+	}
+
+	if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return v, nil
+	}
+	if v, err := strconv.ParseFloat(value, 64); err == nil {
+		return v, nil
+	}
+	if v, err := strconv.ParseBool(value); err == nil {
+		return v, nil
+	}
+	return value, nil
+}
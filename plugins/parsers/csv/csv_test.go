@@ -0,0 +1,212 @@
+package csv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		input  string
+		want   []string
+		tags   []map[string]string
+		fields []map[string]interface{}
+		times  []time.Time
+	}{
+		{
+			name:   "header",
+			config: Config{HeaderRowCount: 1, TagColumns: []string{"host"}},
+			input:  "host,usage,ok,state\na,1.5,true,up\nb,2,false,\n",
+			want:   []string{"csv", "csv"},
+			tags:   []map[string]string{{"host": "a"}, {"host": "b"}},
+			fields: []map[string]interface{}{
+				{"usage": 1.5, "ok": true, "state": "up"},
+				{"usage": int64(2), "ok": false},
+			},
+		},
+		{
+			name:   "multiple header rows",
+			config: Config{HeaderRowCount: 2, MeasurementColumn: "name"},
+			input:  "na,cpu\nme,_usage\nsystem,3\n",
+			want:   []string{"system"},
+			tags:   []map[string]string{{}},
+			fields: []map[string]interface{}{{"cpu_usage": int64(3)}},
+		},
+		{
+			name: "column names and types",
+			config: Config{
+				ColumnNames: []string{"", "value", "count"},
+				ColumnTypes: []string{"string", "string", "float"},
+			},
+			input:  "skipped,1,2\n",
+			want:   []string{"csv"},
+			tags:   []map[string]string{{}},
+			fields: []map[string]interface{}{{"value": "1", "count": 2.0}},
+		},
+		{
+			name: "skip rows and columns",
+			config: Config{
+				HeaderRowCount: 1,
+				SkipRows:       2,
+				SkipColumns:    1,
+				Comment:        "#",
+				Delimiter:      ";",
+				TrimSpace:      true,
+			},
+			input:  "garbage\ngarbage\nid;value\n# comment\n1; 10\n",
+			want:   []string{"csv"},
+			tags:   []map[string]string{{}},
+			fields: []map[string]interface{}{{"value": int64(10)}},
+		},
+		{
+			name: "timestamp",
+			config: Config{
+				HeaderRowCount:  1,
+				TimestampColumn: "time",
+				TimestampFormat: "unix_ms",
+			},
+			input:  "time,value\n1600000000500,1\n",
+			want:   []string{"csv"},
+			tags:   []map[string]string{{}},
+			fields: []map[string]interface{}{{"value": int64(1)}},
+			times:  []time.Time{time.Unix(1600000000, 500000000)},
+		},
+		{
+			name:   "detected header",
+			config: Config{TagColumns: []string{"host"}},
+			input:  "host,usage\na,1.5\n",
+			want:   []string{"csv"},
+			tags:   []map[string]string{{"host": "a"}},
+			fields: []map[string]interface{}{{"usage": 1.5}},
+		},
+		{
+			name:   "detected no header",
+			config: Config{SkipColumns: 1},
+			input:  "a,1.5,up\nb,2,down\n",
+			want:   []string{"csv", "csv"},
+			tags:   []map[string]string{{}, {}},
+			fields: []map[string]interface{}{
+				{"column1": 1.5, "column2": "up"},
+				{"column1": int64(2), "column2": "down"},
+			},
+		},
+		{
+			name:   "header only",
+			config: Config{HeaderRowCount: 1},
+			input:  "a,b\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(&tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			metrics, err := p.Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(metrics) != len(tt.want) {
+				t.Fatalf("got %d metrics, want %d", len(metrics), len(tt.want))
+			}
+			for i, m := range metrics {
+				if m.Name() != tt.want[i] {
+					t.Errorf("name = %q, want %q", m.Name(), tt.want[i])
+				}
+				if !reflect.DeepEqual(m.Tags(), tt.tags[i]) {
+					t.Errorf("tags = %v, want %v", m.Tags(), tt.tags[i])
+				}
+				if !reflect.DeepEqual(m.Fields(), tt.fields[i]) {
+					t.Errorf("fields = %v, want %v", m.Fields(), tt.fields[i])
+				}
+				if tt.times != nil && !m.Time().Equal(tt.times[i]) {
+					t.Errorf("time = %v, want %v", m.Time(), tt.times[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "invalid type",
+			config:  Config{ColumnNames: []string{"a"}, ColumnTypes: []string{"int"}},
+			input:   "1\nx\n",
+			wantErr: "csv: record 2: column a:",
+		},
+		{
+			name: "invalid timestamp",
+			config: Config{
+				HeaderRowCount:  1,
+				TimestampColumn: "time",
+				TimestampFormat: "unix",
+			},
+			input:   "time\nnow\n",
+			wantErr: "csv: record 1:",
+		},
+		{
+			name:    "invalid quoting",
+			config:  Config{HeaderRowCount: 1},
+			input:   "a\n\"x\n",
+			wantErr: "csv:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(&tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = p.Parse([]byte(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "negative header rows", config: Config{HeaderRowCount: -1}},
+		{
+			name:   "types length",
+			config: Config{ColumnNames: []string{"a"}, ColumnTypes: []string{"int", "int"}},
+		},
+		{
+			name:   "invalid type",
+			config: Config{ColumnNames: []string{"a"}, ColumnTypes: []string{"time"}},
+		},
+		{
+			name:   "timestamp format",
+			config: Config{HeaderRowCount: 1, TimestampColumn: "time"},
+		},
+		{
+			name:   "delimiter",
+			config: Config{HeaderRowCount: 1, Delimiter: ",,"},
+		},
+		{
+			name:   "comment is delimiter",
+			config: Config{HeaderRowCount: 1, Delimiter: ";", Comment: ";"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(&tt.config); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
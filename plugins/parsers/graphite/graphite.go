@@ -0,0 +1,139 @@
+package graphite
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/metric"
+)
+
+const (
+	Name = "graphite"
+)
+
+const (
+	defaultSeparator = "."
+	defaultField     = "value"
+)
+
+// Config contains the configuration for the Graphite parser.
+type Config struct {
+	// Separator joins the path segments that make up the measurement, a
+	// tag or the field; defaults to ".".
+	Separator string `toml:"graphite_separator"`
+	// Templates map paths to metrics.  When no template matches a path,
+	// the whole path is used as the measurement.
+	Templates []string `toml:"graphite_templates"`
+}
+
+// Graphite is a parser for the Graphite plaintext protocol, with lines of
+// the form "path value [timestamp]".  The timestamp is in seconds.
+type Graphite struct {
+	separator string
+	templates []*template
+}
+
+func New(config *Config) (telegraf.Parser, error) {
+	separator := config.Separator
+	if separator == "" {
+		separator = defaultSeparator
+	}
+
+	templates := make([]*template, 0, len(config.Templates))
+	for _, s := range config.Templates {
+		t, err := parseTemplate(s, separator)
+		if err != nil {
+			return nil, fmt.Errorf("graphite: %v", err)
+		}
+		templates = append(templates, t)
+	}
+
+	return &Graphite{separator: separator, templates: templates}, nil
+}
+
+func (p *Graphite) Parse(buf []byte) ([]telegraf.Metric, error) {
+	now := time.Now()
+	metrics := make([]telegraf.Metric, 0)
+
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		m, err := p.parseLine(line, now)
+		if err != nil {
+			return nil, fmt.Errorf("graphite: line %d: %v", lineno, err)
+		}
+		metrics = append(metrics, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("graphite: %v", err)
+	}
+	return metrics, nil
+}
+
+func (p *Graphite) parseLine(line string, now time.Time) (telegraf.Metric, error) {
+	words := strings.Fields(line)
+	if len(words) < 2 || len(words) > 3 {
+		return nil, fmt.Errorf("expected \"path value [timestamp]\": %q", line)
+	}
+
+	value, err := strconv.ParseFloat(words[1], 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, fmt.Errorf("invalid value: %q", words[1])
+	}
+
+	tm := now
+	if len(words) == 3 {
+		ts, err := strconv.ParseFloat(words[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp: %q", words[2])
+		}
+		// Senders without a clock may send -1 to use the time received.
+		if ts != -1 {
+			whole, frac := math.Modf(ts)
+			tm = time.Unix(int64(whole), int64(frac*1e9))
+		}
+	}
+
+	segments := strings.Split(words[0], ".")
+	name, tags, field := words[0], map[string]string{}, ""
+	if t := p.lookup(segments); t != nil {
+		name, tags, field = t.apply(segments)
+		if name == "" {
+			name = words[0]
+		}
+	}
+	if field == "" {
+		field = defaultField
+	}
+
+	return metric.New(name, tags, map[string]interface{}{field: value}, tm)
+}
+
+// lookup returns the most specific template matching the path, the first
+// template defined wins a tie.
+func (p *Graphite) lookup(segments []string) *template {
+	var best *template
+	var bestLen, bestWild int
+	for _, t := range p.templates {
+		if !t.match(segments) {
+			continue
+		}
+		length, wild := t.specificity()
+		if best == nil || length > bestLen || (length == bestLen && wild > bestWild) {
+			best, bestLen, bestWild = t, length, wild
+		}
+	}
+	return best
+}
@@ -0,0 +1,127 @@
+package graphite
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		input  string
+		want   string
+		tags   map[string]string
+		fields map[string]interface{}
+		time   time.Time
+	}{
+		{
+			name:   "no template",
+			input:  "servers.web01.cpu 1.5 1600000000\n",
+			want:   "servers.web01.cpu",
+			tags:   map[string]string{},
+			fields: map[string]interface{}{"value": 1.5},
+			time:   time.Unix(1600000000, 0),
+		},
+		{
+			name:   "template",
+			config: Config{Templates: []string{".host.measurement.field"}},
+			input:  "servers.web01.cpu.usage 2 1600000000.5",
+			want:   "cpu",
+			tags:   map[string]string{"host": "web01"},
+			fields: map[string]interface{}{"usage": 2.0},
+			time:   time.Unix(1600000000, 500000000),
+		},
+		{
+			name: "most specific filter",
+			config: Config{Templates: []string{
+				"servers.* .host.measurement*",
+				"servers.*.disk .host.measurement.field region=eu",
+			}},
+			input:  "servers.web01.disk.free 3 1600000000",
+			want:   "disk",
+			tags:   map[string]string{"host": "web01", "region": "eu"},
+			fields: map[string]interface{}{"free": 3.0},
+			time:   time.Unix(1600000000, 0),
+		},
+		{
+			name:   "greedy measurement with separator",
+			config: Config{Separator: "_", Templates: []string{"servers.* .host.measurement*"}},
+			input:  "servers.web01.disk.free 3 1600000000",
+			want:   "disk_free",
+			tags:   map[string]string{"host": "web01"},
+			fields: map[string]interface{}{"value": 3.0},
+			time:   time.Unix(1600000000, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(&tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			metrics, err := p.Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(metrics) != 1 {
+				t.Fatalf("got %d metrics, want 1", len(metrics))
+			}
+			m := metrics[0]
+			if m.Name() != tt.want {
+				t.Errorf("name = %q, want %q", m.Name(), tt.want)
+			}
+			if !reflect.DeepEqual(m.Tags(), tt.tags) {
+				t.Errorf("tags = %v, want %v", m.Tags(), tt.tags)
+			}
+			if !reflect.DeepEqual(m.Fields(), tt.fields) {
+				t.Errorf("fields = %v, want %v", m.Fields(), tt.fields)
+			}
+			if !m.Time().Equal(tt.time) {
+				t.Errorf("time = %v, want %v", m.Time(), tt.time)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "missing value", input: "cpu"},
+		{name: "invalid value", input: "cpu abc"},
+		{name: "nan", input: "cpu NaN"},
+		{name: "invalid timestamp", input: "cpu 1 abc"},
+		{name: "too many words", input: "cpu 1 2 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(&Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := p.Parse([]byte(tt.input)); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestNewInvalidTemplate(t *testing.T) {
+	tests := []string{
+		"host.field",
+		"measurement*.host",
+		"a b c d",
+		"[ measurement",
+		"measurement region",
+	}
+	for _, template := range tests {
+		t.Run(template, func(t *testing.T) {
+			if _, err := New(&Config{Templates: []string{template}}); err == nil {
+				t.Fatalf("expected error for template %q", template)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package graphite
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// template maps the segments of a path to the measurement, tags and field.
+//
+// A template is written as "[filter] template [tags]", for example:
+//
+//	cpu.* measurement.host.field region=us-west
+//
+// The filter selects the paths the template applies to, each segment is a
+// glob matched against the leading segments of the path.  Each segment of
+// the template names what the path segment is used for: "measurement",
+// "field", a tag key or empty to skip the segment.  "measurement*" and
+// "field*" consume all remaining segments.  Segments used for the same
+// purpose are joined with the separator.  The optional tags are added to
+// every metric matched by the template.
+type template struct {
+	filter    []string
+	parts     []string
+	tags      map[string]string
+	separator string
+}
+
+func parseTemplate(s string, separator string) (*template, error) {
+	words := strings.Fields(s)
+	t := &template{tags: make(map[string]string), separator: separator}
+
+	switch {
+	case len(words) == 1:
+		t.parts = strings.Split(words[0], ".")
+	case len(words) == 2 && strings.Contains(words[1], "="):
+		t.parts = strings.Split(words[0], ".")
+		if err := t.parseTags(words[1]); err != nil {
+			return nil, err
+		}
+	case len(words) == 2:
+		t.filter = strings.Split(words[0], ".")
+		t.parts = strings.Split(words[1], ".")
+	case len(words) == 3:
+		t.filter = strings.Split(words[0], ".")
+		t.parts = strings.Split(words[1], ".")
+		if err := t.parseTags(words[2]); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid template: %q", s)
+	}
+
+	for _, pattern := range t.filter {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid template filter: %q", s)
+		}
+	}
+
+	measurement := false
+	for i, part := range t.parts {
+		switch part {
+		case "measurement", "measurement*":
+			measurement = true
+		}
+		if strings.HasSuffix(part, "*") && i != len(t.parts)-1 {
+			return nil, fmt.Errorf("invalid template, %s must be last: %q", part, s)
+		}
+	}
+	if !measurement {
+		return nil, fmt.Errorf("invalid template, no measurement: %q", s)
+	}
+	return t, nil
+}
+
+func (t *template) parseTags(s string) error {
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return fmt.Errorf("invalid template tags: %q", s)
+		}
+		t.tags[kv[0]] = kv[1]
+	}
+	return nil
+}
+
+// match reports if the filter of the template matches the path segments.
+// A template without a filter matches every path.
+func (t *template) match(segments []string) bool {
+	if len(t.filter) > len(segments) {
+		return false
+	}
+	for i, pattern := range t.filter {
+		if ok, _ := path.Match(pattern, segments[i]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// specificity orders matching templates, longer filters with fewer
+// wildcards are more specific.
+func (t *template) specificity() (int, int) {
+	wildcards := 0
+	for _, pattern := range t.filter {
+		if strings.ContainsAny(pattern, "*?[") {
+			wildcards++
+		}
+	}
+	return len(t.filter), -wildcards
+}
+
+// apply returns the measurement, tags and field of the path segments.  The
+// field is empty if the template has no field.
+func (t *template) apply(segments []string) (string, map[string]string, string) {
+	var measurement, field []string
+	tags := make(map[string][]string)
+
+	for i, part := range t.parts {
+		if i >= len(segments) {
+			break
+		}
+		switch part {
+		case "":
+		case "measurement":
+			measurement = append(measurement, segments[i])
+		case "measurement*":
+			measurement = append(measurement, segments[i:]...)
+		case "field":
+			field = append(field, segments[i])
+		case "field*":
+			field = append(field, segments[i:]...)
+		default:
+			tags[part] = append(tags[part], segments[i])
+		}
+	}
+
+	out := make(map[string]string, len(tags)+len(t.tags))
+	for key, value := range t.tags {
+		out[key] = value
+	}
+	for key, values := range tags {
+		out[key] = strings.Join(values, t.separator)
+	}
+	return strings.Join(measurement, t.separator), out, strings.Join(field, t.separator)
+}
@@ -0,0 +1,338 @@
+package grok
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/internal/timestamp"
+	"github.com/influxdata/tgconfig/metric"
+)
+
+const (
+	Name = "grok"
+)
+
+const (
+	defaultMetricName = "grok"
+
+	// maxDepth limits the nesting of patterns, catching patterns that
+	// refer to themselves.
+	maxDepth = 32
+)
+
+// Config contains the configuration for the Grok parser.
+type Config struct {
+	// Patterns are matched against each line in turn, the first to match
+	// is used.
+	Patterns []string `toml:"grok_patterns"`
+	// CustomPatterns defines additional named patterns, one per line as
+	// "NAME expression".
+	CustomPatterns string `toml:"grok_custom_patterns"`
+	// CustomPatternFiles are files of additional named patterns in the
+	// same form as CustomPatterns.
+	CustomPatternFiles []string `toml:"grok_custom_pattern_files"`
+	// Timezone is the location used for timestamps without a zone,
+	// defaults to UTC.
+	Timezone string `toml:"grok_timezone"`
+}
+
+// Grok is a parser for unstructured text such as logs.  Patterns are
+// regular expressions that may refer to named patterns as %{NAME}.  The
+// text matched by %{NAME:key} or %{NAME:key:modifier} is captured as a
+// field of the metric, with the modifier selecting its type:
+//
+//	string, int, float, bool  field of the type, defaults to string with
+//	                          any surrounding quotes removed
+//	duration                  integer field of nanoseconds
+//	tag                       tag
+//	drop                      discarded
+//	measurement               the measurement name, defaults to "grok"
+//	ts-<format>               the timestamp, format is "unix", "unix_ms",
+//	                          "unix_us", "unix_ns", "ansic", "rfc3339",
+//	                          "httpd", "syslog" or a quoted Go layout
+//
+// Lines that do not match any pattern are skipped, as are captures that
+// cannot be converted to their type.  A timestamp that cannot be parsed is
+// an error.
+type Grok struct {
+	patterns []*pattern
+	location *time.Location
+}
+
+// pattern is a compiled pattern along with the key and modifier of each of
+// its captures.
+type pattern struct {
+	re       *regexp.Regexp
+	captures []capture
+	// groups maps each subexpression of re to its capture, or -1 for
+	// subexpressions that are not captured.
+	groups []int
+}
+
+type capture struct {
+	key      string
+	modifier string
+}
+
+// layouts are the named timestamp formats of the ts modifier.
+var layouts = map[string]string{
+	"ansic":   time.ANSIC,
+	"rfc3339": time.RFC3339Nano,
+	"httpd":   "02/Jan/2006:15:04:05 -0700",
+	"syslog":  "Jan _2 15:04:05",
+}
+
+var reference = regexp.MustCompile(`%\{(\w+)(?::([^:}]+))?(?::([^}]+))?\}`)
+
+func New(config *Config) (telegraf.Parser, error) {
+	if len(config.Patterns) == 0 {
+		return nil, fmt.Errorf("grok: grok_patterns is required")
+	}
+
+	library := make(map[string]string)
+	if err := addPatterns(library, defaultPatterns); err != nil {
+		return nil, err
+	}
+	for _, path := range config.CustomPatternFiles {
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("grok: %v", err)
+		}
+		if err := addPatterns(library, string(buf)); err != nil {
+			return nil, err
+		}
+	}
+	if err := addPatterns(library, config.CustomPatterns); err != nil {
+		return nil, err
+	}
+
+	p := &Grok{location: time.UTC}
+	if config.Timezone != "" {
+		location, err := time.LoadLocation(config.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("grok: %v", err)
+		}
+		p.location = location
+	}
+
+	for _, s := range config.Patterns {
+		compiled, err := compile(library, s)
+		if err != nil {
+			return nil, fmt.Errorf("grok: pattern %q: %v", s, err)
+		}
+		p.patterns = append(p.patterns, compiled)
+	}
+	return p, nil
+}
+
+// addPatterns adds the "NAME expression" lines of s to the library.
+func addPatterns(library map[string]string, s string) error {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("grok: invalid pattern definition: %q", line)
+		}
+		library[parts[0]] = strings.TrimSpace(parts[1])
+	}
+	return nil
+}
+
+// compile expands the references to named patterns and compiles the
+// result.
+func compile(library map[string]string, s string) (*pattern, error) {
+	p := &pattern{}
+	// names maps the name of each group added for a capture to its index
+	// in captures.
+	names := make(map[string]int)
+
+	var expand func(s string, depth int) (string, error)
+	expand = func(s string, depth int) (string, error) {
+		if depth > maxDepth {
+			return "", fmt.Errorf("patterns nested too deeply")
+		}
+
+		var err error
+		expanded := reference.ReplaceAllStringFunc(s, func(ref string) string {
+			if err != nil {
+				return ""
+			}
+			match := reference.FindStringSubmatch(ref)
+			name, key, modifier := match[1], match[2], match[3]
+
+			def, ok := library[name]
+			if !ok {
+				err = fmt.Errorf("unknown pattern %s", name)
+				return ""
+			}
+			var sub string
+			sub, err = expand(def, depth+1)
+			if err != nil {
+				return ""
+			}
+
+			if key == "" {
+				return "(?:" + sub + ")"
+			}
+			if err = checkModifier(modifier); err != nil {
+				return ""
+			}
+			group := fmt.Sprintf("c%d", len(p.captures))
+			names[group] = len(p.captures)
+			p.captures = append(p.captures, capture{key: key, modifier: modifier})
+			return "(?P<" + group + ">" + sub + ")"
+		})
+		return expanded, err
+	}
+
+	expanded, err := expand(s, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	p.re, err = regexp.Compile(expanded)
+	if err != nil {
+		return nil, err
+	}
+
+	// Groups named in the pattern itself are not captured, unless they
+	// reuse the name of a capture and so cannot be told apart from it.
+	seen := make(map[string]bool)
+	p.groups = make([]int, len(p.re.SubexpNames()))
+	for i, name := range p.re.SubexpNames() {
+		p.groups[i] = -1
+		index, ok := names[name]
+		if !ok {
+			continue
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("group name %s is reserved for captures", name)
+		}
+		seen[name] = true
+		p.groups[i] = index
+	}
+	return p, nil
+}
+
+func checkModifier(modifier string) error {
+	switch modifier {
+	case "", "string", "int", "float", "bool", "duration", "tag", "drop", "measurement":
+		return nil
+	}
+	if strings.HasPrefix(modifier, "ts-") {
+		return nil
+	}
+	return fmt.Errorf("unknown modifier %s", modifier)
+}
+
+func (p *Grok) Parse(buf []byte) ([]telegraf.Metric, error) {
+	now := time.Now()
+	metrics := make([]telegraf.Metric, 0)
+
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		m, err := p.parseLine(scanner.Text(), now)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			metrics = append(metrics, m)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("grok: %v", err)
+	}
+	return metrics, nil
+}
+
+// parseLine returns the metric of the first matching pattern, or nil if no
+// pattern matches or the match has no fields.
+func (p *Grok) parseLine(line string, now time.Time) (telegraf.Metric, error) {
+	for _, pat := range p.patterns {
+		values := pat.re.FindStringSubmatch(line)
+		if values == nil {
+			continue
+		}
+
+		name := defaultMetricName
+		tm := now
+		tags := make(map[string]string)
+		fields := make(map[string]interface{})
+
+		for i, value := range values {
+			if pat.groups[i] < 0 || value == "" {
+				continue
+			}
+			c := pat.captures[pat.groups[i]]
+
+			switch c.modifier {
+			case "tag":
+				tags[c.key] = value
+			case "drop":
+			case "measurement":
+				name = value
+			case "", "string":
+				fields[c.key] = strings.Trim(value, `"`)
+			default:
+				if strings.HasPrefix(c.modifier, "ts-") {
+					t, err := p.parseTime(strings.TrimPrefix(c.modifier, "ts-"), value)
+					if err != nil {
+						return nil, fmt.Errorf("grok: invalid timestamp %q for %s: %v", value, c.key, err)
+					}
+					tm = t
+					continue
+				}
+				if v, ok := convert(c.modifier, value); ok {
+					fields[c.key] = v
+				}
+			}
+		}
+
+		if len(fields) == 0 {
+			return nil, nil
+		}
+		m, err := metric.New(name, tags, fields, tm)
+		if err != nil {
+			return nil, fmt.Errorf("grok: %v", err)
+		}
+		return m, nil
+	}
+	return nil, nil
+}
+
+func (p *Grok) parseTime(format string, value string) (time.Time, error) {
+	if layout, ok := layouts[format]; ok {
+		format = layout
+	}
+	format = strings.Trim(format, `"`)
+	return timestamp.Parse(format, value, p.location)
+}
+
+// convert converts a captured value to the type of the modifier.
+func convert(modifier string, value string) (interface{}, bool) {
+	switch modifier {
+	case "int":
+		v, err := strconv.ParseInt(value, 10, 64)
+		return v, err == nil
+	case "float":
+		v, err := strconv.ParseFloat(value, 64)
+		return v, err == nil
+	case "bool":
+		v, err := strconv.ParseBool(value)
+		return v, err == nil
+	case "duration":
+		v, err := time.ParseDuration(value)
+		return int64(v), err == nil
+	}
+	return nil, false
+}
@@ -0,0 +1,299 @@
+package grok
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompile(t *testing.T) {
+	library := map[string]string{
+		"DIGITS": `\d+`,
+		"PAIR":   `%{DIGITS:a:int}-%{DIGITS:b}`,
+		"LOOP":   `%{LOOP}`,
+	}
+	tests := []struct {
+		name         string
+		pattern      string
+		wantExpr     string
+		wantCaptures []capture
+		wantErr      string
+	}{
+		{
+			name:     "unnamed",
+			pattern:  `x%{DIGITS}`,
+			wantExpr: `x(?:\d+)`,
+		},
+		{
+			name:     "nested",
+			pattern:  `%{PAIR} %{DIGITS:c:tag}`,
+			wantExpr: `(?:(?P<c0>\d+)-(?P<c1>\d+)) (?P<c2>\d+)`,
+			wantCaptures: []capture{
+				{key: "a", modifier: "int"},
+				{key: "b"},
+				{key: "c", modifier: "tag"},
+			},
+		},
+		{
+			name:     "timestamp modifier",
+			pattern:  `%{DIGITS:ts:ts-unix}`,
+			wantExpr: `(?P<c0>\d+)`,
+			wantCaptures: []capture{
+				{key: "ts", modifier: "ts-unix"},
+			},
+		},
+		{
+			name:     "named group",
+			pattern:  `(?P<c7>x) %{DIGITS:a}`,
+			wantExpr: `(?P<c7>x) (?P<c0>\d+)`,
+			wantCaptures: []capture{
+				{key: "a"},
+			},
+		},
+		{name: "capture group name", pattern: `(?P<c0>x) %{DIGITS:a}`, wantErr: "group name c0 is reserved"},
+		{name: "unknown pattern", pattern: `%{MISSING}`, wantErr: "unknown pattern MISSING"},
+		{name: "recursive", pattern: `%{LOOP}`, wantErr: "nested too deeply"},
+		{name: "unknown modifier", pattern: `%{DIGITS:a:hex}`, wantErr: "unknown modifier hex"},
+		{name: "invalid expression", pattern: `(`, wantErr: "missing closing )"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := compile(library, tt.pattern)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("compile() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.re.String() != tt.wantExpr {
+				t.Errorf("expr = %s, want %s", p.re.String(), tt.wantExpr)
+			}
+			if !reflect.DeepEqual(p.captures, tt.wantCaptures) {
+				t.Errorf("captures = %v, want %v", p.captures, tt.wantCaptures)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     Config
+		input      string
+		wantName   string
+		wantTags   map[string]string
+		wantFields map[string]interface{}
+		wantTime   time.Time
+	}{
+		{
+			name: "common log format",
+			config: Config{
+				Patterns: []string{"%{COMMON_LOG_FORMAT}"},
+			},
+			input:    `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326`,
+			wantName: "grok",
+			wantTags: map[string]string{
+				"client_ip": "127.0.0.1",
+				"verb":      "GET",
+				"resp_code": "200",
+			},
+			wantFields: map[string]interface{}{
+				"ident":        "-",
+				"auth":         "frank",
+				"request":      "/a.gif",
+				"http_version": 1.0,
+				"resp_bytes":   int64(2326),
+			},
+			wantTime: time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC),
+		},
+		{
+			name: "modifiers",
+			config: Config{
+				Patterns: []string{
+					`%{WORD:name:measurement} %{NUMBER:value:float} %{WORD:ok:bool} %{NOTSPACE:took:duration} %{QUOTEDSTRING:msg} %{WORD:skip:drop}`,
+				},
+			},
+			input:    `cpu 0.5 true 1.5s "busy" x`,
+			wantName: "cpu",
+			wantTags: map[string]string{},
+			wantFields: map[string]interface{}{
+				"value": 0.5,
+				"ok":    true,
+				"took":  int64(1500 * time.Millisecond),
+				"msg":   "busy",
+			},
+		},
+		{
+			name: "unconvertible values are skipped",
+			config: Config{
+				Patterns: []string{`%{WORD:a:int} %{WORD:b}`},
+			},
+			input:      "x y",
+			wantName:   "grok",
+			wantTags:   map[string]string{},
+			wantFields: map[string]interface{}{"b": "y"},
+		},
+		{
+			name: "named groups are not captured",
+			config: Config{
+				Patterns: []string{`(?P<c7>\w+) %{INT:a:int}`},
+			},
+			input:      "x 1",
+			wantName:   "grok",
+			wantTags:   map[string]string{},
+			wantFields: map[string]interface{}{"a": int64(1)},
+		},
+		{
+			name: "first matching pattern",
+			config: Config{
+				Patterns: []string{`^a=%{INT:a:int}$`, `^%{WORD:b}`},
+			},
+			input:      "b c",
+			wantName:   "grok",
+			wantTags:   map[string]string{},
+			wantFields: map[string]interface{}{"b": "b"},
+		},
+		{
+			name: "custom patterns and timezone",
+			config: Config{
+				Patterns:       []string{`%{STAMP:ts:ts-"2006-01-02 15:04:05"} %{ITEM:item}`},
+				CustomPatterns: "# comment\nSTAMP %{YEAR}-%{MONTHNUM}-%{MONTHDAY} %{TIME}\nITEM %{WORD}\n",
+				Timezone:       "America/New_York",
+			},
+			input:      "2020-01-02 03:04:05 thing",
+			wantName:   "grok",
+			wantTags:   map[string]string{},
+			wantFields: map[string]interface{}{"item": "thing"},
+			wantTime:   time.Date(2020, 1, 2, 8, 4, 5, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(&tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			metrics, err := p.Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(metrics) != 1 {
+				t.Fatalf("got %d metrics, want 1", len(metrics))
+			}
+			m := metrics[0]
+			if m.Name() != tt.wantName {
+				t.Errorf("name = %q, want %q", m.Name(), tt.wantName)
+			}
+			if !reflect.DeepEqual(m.Tags(), tt.wantTags) {
+				t.Errorf("tags = %v, want %v", m.Tags(), tt.wantTags)
+			}
+			if !reflect.DeepEqual(m.Fields(), tt.wantFields) {
+				t.Errorf("fields = %v, want %v", m.Fields(), tt.wantFields)
+			}
+			if !tt.wantTime.IsZero() && !m.Time().Equal(tt.wantTime) {
+				t.Errorf("time = %v, want %v", m.Time(), tt.wantTime)
+			}
+		})
+	}
+}
+
+func TestParseSkipsUnmatched(t *testing.T) {
+	p, err := New(&Config{Patterns: []string{`^%{INT:a:int}$`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics, err := p.Parse([]byte("x\n1\n\n2 3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics) != 1 || metrics[0].Fields()["a"] != int64(1) {
+		t.Fatalf("metrics = %v, want a single metric with a=1", metrics)
+	}
+}
+
+func TestParseInvalidTimestamp(t *testing.T) {
+	p, err := New(&Config{Patterns: []string{`%{WORD:ts:ts-unix} %{INT:a:int}`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Parse([]byte("soon 1"))
+	if err == nil || !strings.Contains(err.Error(), `invalid timestamp "soon" for ts`) {
+		t.Fatalf("Parse() error = %v, want invalid timestamp", err)
+	}
+}
+
+func TestCustomPatternFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "patterns")
+	if err := ioutil.WriteFile(path, []byte("LEVEL %{LOGLEVEL}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := New(&Config{
+		Patterns:           []string{`%{LEVEL:level:tag} %{INT:code:int}`},
+		CustomPatternFiles: []string{path},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics, err := p.Parse([]byte("WARN 3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics) != 1 || metrics[0].Tags()["level"] != "WARN" {
+		t.Fatalf("metrics = %v, want a single metric with level=WARN", metrics)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{
+			name:    "no patterns",
+			wantErr: "grok_patterns is required",
+		},
+		{
+			name:    "invalid definition",
+			config:  Config{Patterns: []string{"x"}, CustomPatterns: "NOEXPR"},
+			wantErr: "invalid pattern definition",
+		},
+		{
+			name:    "missing pattern file",
+			config:  Config{Patterns: []string{"x"}, CustomPatternFiles: []string{"/nonexistent/patterns"}},
+			wantErr: "no such file",
+		},
+		{
+			name:    "invalid timezone",
+			config:  Config{Patterns: []string{"x"}, Timezone: "Nowhere/Place"},
+			wantErr: "unknown time zone",
+		},
+		{
+			name:    "invalid pattern",
+			config:  Config{Patterns: []string{"%{NOPE}"}},
+			wantErr: `pattern "%{NOPE}": unknown pattern NOPE`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(&tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("New() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package influx
 
 import (
+	"fmt"
+	"io"
+	"time"
+
 	telegraf "github.com/influxdata/tgconfig"
 )
 
@@ -8,14 +12,84 @@ const (
 	Name = "influx"
 )
 
-type Config struct{}
+// Config contains the configuration for the Influx parser.
+type Config struct {
+	// TimestampPrecision is the unit of the timestamps, one of "1ns",
+	// "1us", "1ms" or "1s"; defaults to "1ns".
+	TimestampPrecision telegraf.Duration `toml:"influx_timestamp_precision"`
+}
 
-type Influx struct{}
+// TimeFunc returns the time used for metrics without a timestamp.
+type TimeFunc func() time.Time
 
-func (i *Influx) Parse(buf []byte) ([]telegraf.Metric, error) {
-	return []telegraf.Metric{}, nil
+// Influx is a parser for the InfluxDB line protocol.
+type Influx struct {
+	precision time.Duration
+	timeFunc  TimeFunc
 }
 
 func New(config *Config) (telegraf.Parser, error) {
-	return &Influx{}, nil
+	precision, err := checkPrecision(config.TimestampPrecision.Duration)
+	if err != nil {
+		return nil, err
+	}
+	return &Influx{precision: precision, timeFunc: time.Now}, nil
+}
+
+// checkPrecision returns the precision, defaulting to nanoseconds, or an
+// error if it is not a supported unit.
+func checkPrecision(precision time.Duration) (time.Duration, error) {
+	switch precision {
+	case 0:
+		return time.Nanosecond, nil
+	case time.Nanosecond, time.Microsecond, time.Millisecond, time.Second:
+		return precision, nil
+	}
+	return 0, fmt.Errorf("influx: invalid timestamp precision: %s", precision)
+}
+
+// SetTimeFunc sets the function used to obtain the time of metrics without
+// a timestamp, defaults to time.Now.
+func (p *Influx) SetTimeFunc(f TimeFunc) {
+	p.timeFunc = f
+}
+
+// NewStreamParser creates a StreamParser reading from r with the precision
+// and time function of the parser.
+func (p *Influx) NewStreamParser(r io.Reader) *StreamParser {
+	s := NewStreamParser(r)
+	s.precision = p.precision
+	s.timeFunc = p.timeFunc
+	return s
+}
+
+// Parse parses all metrics in the buffer.  Metrics without a timestamp all
+// receive the same time.
+func (p *Influx) Parse(buf []byte) ([]telegraf.Metric, error) {
+	m := newMachine(buf, 1, p.precision)
+	now := p.timeFunc()
+
+	metrics := make([]telegraf.Metric, 0)
+	for {
+		metric, err := m.next(now)
+		if err != nil {
+			return nil, err
+		}
+		if metric == nil {
+			return metrics, nil
+		}
+		metrics = append(metrics, metric)
+	}
+}
+
+// ParseLine parses a single metric.
+func (p *Influx) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+	if len(metrics) != 1 {
+		return nil, fmt.Errorf("influx: expected 1 metric, found %d", len(metrics))
+	}
+	return metrics[0], nil
 }
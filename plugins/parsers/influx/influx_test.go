@@ -0,0 +1,188 @@
+package influx
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+var testNow = time.Unix(1600000000, 0)
+
+func newTestParser(t *testing.T, config *Config) *Influx {
+	t.Helper()
+	parser, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := parser.(*Influx)
+	p.SetTimeFunc(func() time.Time { return testNow })
+	return p
+}
+
+type testMetric struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+	time   time.Time
+}
+
+func checkMetrics(t *testing.T, metrics []telegraf.Metric, want []testMetric) {
+	t.Helper()
+	if len(metrics) != len(want) {
+		t.Fatalf("got %d metrics, want %d", len(metrics), len(want))
+	}
+	for i, m := range metrics {
+		if m.Name() != want[i].name {
+			t.Errorf("name = %q, want %q", m.Name(), want[i].name)
+		}
+		if !reflect.DeepEqual(m.Tags(), want[i].tags) {
+			t.Errorf("tags = %v, want %v", m.Tags(), want[i].tags)
+		}
+		if !reflect.DeepEqual(m.Fields(), want[i].fields) {
+			t.Errorf("fields = %v, want %v", m.Fields(), want[i].fields)
+		}
+		if !m.Time().Equal(want[i].time) {
+			t.Errorf("time = %v, want %v", m.Time(), want[i].time)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		input  string
+		want   []testMetric
+	}{
+		{
+			name:  "minimal",
+			input: "cpu value=1",
+			want: []testMetric{
+				{"cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, testNow},
+			},
+		},
+		{
+			name:  "tags and timestamp",
+			input: "cpu,host=a,cpu=cpu0 usage=1.5 1600000000000000001\n",
+			want: []testMetric{
+				{"cpu", map[string]string{"host": "a", "cpu": "cpu0"},
+					map[string]interface{}{"usage": 1.5}, time.Unix(1600000000, 1)},
+			},
+		},
+		{
+			name:  "field types",
+			input: `m i=-1i,u=2u,f=-1.5e3,t=true,F=F,s="a \"b\" \\ c"`,
+			want: []testMetric{
+				{"m", map[string]string{}, map[string]interface{}{
+					"i": int64(-1), "u": uint64(2), "f": -1500.0,
+					"t": true, "F": false, "s": `a "b" \ c`,
+				}, testNow},
+			},
+		},
+		{
+			name:  "escapes",
+			input: `my\ cpu\,x,ta\=g\ k=v\,1 f\ k=1`,
+			want: []testMetric{
+				{"my cpu,x", map[string]string{"ta=g k": "v,1"},
+					map[string]interface{}{"f k": 1.0}, testNow},
+			},
+		},
+		{
+			name:  "multi line string",
+			input: "log msg=\"line 1\nline 2\" 5\ncpu value=1 6\n",
+			want: []testMetric{
+				{"log", map[string]string{}, map[string]interface{}{"msg": "line 1\nline 2"}, time.Unix(0, 5)},
+				{"cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(0, 6)},
+			},
+		},
+		{
+			name:  "comments and blank lines",
+			input: "# comment\n\n  \r\ncpu value=1 1\r\n# done\n",
+			want: []testMetric{
+				{"cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(0, 1)},
+			},
+		},
+		{
+			name:   "precision",
+			config: Config{TimestampPrecision: telegraf.Duration{Duration: time.Second}},
+			input:  "cpu value=1 1600000000",
+			want: []testMetric{
+				{"cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, testNow},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics, err := newTestParser(t, &tt.config).Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkMetrics(t, metrics, tt.want)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	seconds := Config{TimestampPrecision: telegraf.Duration{Duration: time.Second}}
+	tests := []struct {
+		name   string
+		config Config
+		input  string
+		line   int
+		column int
+		msg    string
+	}{
+		{name: "no measurement", input: ",a=b f=1", line: 1, column: 1, msg: "expected measurement"},
+		{name: "no fields", input: "cpu", line: 1, column: 4, msg: "missing fields"},
+		{name: "no tag value", input: "cpu,a= f=1", line: 1, column: 7, msg: "expected tag value"},
+		{name: "no field value", input: "cpu f=", line: 1, column: 7, msg: "expected field value"},
+		{name: "invalid integer", input: "cpu f=1.5i", line: 1, column: 7, msg: "invalid field value"},
+		{name: "nan", input: "cpu f=nan", line: 1, column: 7, msg: "invalid field value"},
+		{name: "positive infinity", input: "cpu f=+Inf", line: 1, column: 7, msg: "invalid field value"},
+		{name: "negative infinity", input: "cpu f=-inf", line: 1, column: 7, msg: "invalid field value"},
+		{name: "float overflow", input: "cpu f=1e400", line: 1, column: 7, msg: "invalid field value"},
+		{name: "invalid timestamp", input: "cpu f=1 abc", line: 1, column: 9, msg: "invalid timestamp"},
+		{name: "timestamp overflow", config: seconds, input: "cpu f=1 9300000000000000000", line: 1, column: 9, msg: "invalid timestamp"},
+		{name: "timestamp out of range", config: seconds, input: "cpu f=1 9223372037", line: 1, column: 9, msg: "timestamp out of range"},
+		{name: "negative timestamp out of range", config: seconds, input: "cpu f=1 -9223372037", line: 1, column: 9, msg: "timestamp out of range"},
+		{name: "trailing text", input: "cpu f=1 1 x", line: 1, column: 11, msg: "unexpected text after timestamp"},
+		{name: "unterminated string", input: "cpu f=1\ncpu s=\"abc", line: 2, column: 11, msg: "unterminated string"},
+		{name: "second line", input: "cpu f=1\n\ncpu f=x", line: 3, column: 7, msg: "invalid field value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestParser(t, &tt.config).Parse([]byte(tt.input))
+			perr, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("Parse() error = %v, want *ParseError", err)
+			}
+			if perr.Line != tt.line || perr.Column != tt.column || perr.Msg != tt.msg {
+				t.Errorf("Parse() error at %d:%d %q, want %d:%d %q",
+					perr.Line, perr.Column, perr.Msg, tt.line, tt.column, tt.msg)
+			}
+		})
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	p := newTestParser(t, &Config{})
+	if _, err := p.ParseLine("cpu value=1\ncpu value=2"); err == nil {
+		t.Error("expected error for two metrics")
+	}
+	m, err := p.ParseLine("cpu value=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name() != "cpu" {
+		t.Errorf("name = %q, want cpu", m.Name())
+	}
+}
+
+func TestNewInvalidPrecision(t *testing.T) {
+	_, err := New(&Config{TimestampPrecision: telegraf.Duration{Duration: time.Minute}})
+	if err == nil {
+		t.Fatal("expected error for invalid precision")
+	}
+}
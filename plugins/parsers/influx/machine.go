@@ -0,0 +1,322 @@
+package influx
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/metric"
+)
+
+// maxErrorContext limits how much of the line is included in a ParseError.
+const maxErrorContext = 64
+
+// ParseError reports where in the input a metric could not be parsed.
+// Lines and columns start at 1.
+type ParseError struct {
+	Line   int
+	Column int
+	Msg    string
+	// Text is the line containing the error.
+	Text string
+
+	// incomplete is set when the input ended in the middle of a metric.
+	incomplete bool
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("metric parse error: %s at %d:%d: %q",
+		e.Msg, e.Line, e.Column, e.Text)
+}
+
+// machine parses line protocol from a buffer, one metric at a time.
+//
+// The syntax of each line is:
+//
+//	measurement[,tag_key=tag_value...] field_key=field_value[,...] [timestamp]
+//
+// Commas and spaces are escaped with a backslash in measurements; commas,
+// equals signs and spaces in tag keys, tag values and field keys.  String
+// field values are double quoted with double quotes and backslashes
+// escaped.  Blank lines and lines starting with # are skipped.
+type machine struct {
+	buf       []byte
+	pos       int
+	line      int
+	lineStart int
+	precision time.Duration
+}
+
+func newMachine(buf []byte, line int, precision time.Duration) *machine {
+	return &machine{buf: buf, line: line, precision: precision}
+}
+
+// next parses the next metric, returning nil once the buffer is consumed.
+// Metrics without a timestamp are given the time now.  After an error the
+// rest of the line is skipped.
+func (m *machine) next(now time.Time) (telegraf.Metric, error) {
+	for {
+		m.skip(" \t\r")
+		if m.eof() {
+			return nil, nil
+		}
+		switch m.buf[m.pos] {
+		case '\n':
+			m.newline()
+			continue
+		case '#':
+			m.skipLine()
+			continue
+		}
+		break
+	}
+
+	metric, err := m.metric(now)
+	if err != nil {
+		m.skipLine()
+		return nil, err
+	}
+	return metric, nil
+}
+
+func (m *machine) metric(now time.Time) (telegraf.Metric, error) {
+	name := m.key(", ", ", ")
+	if name == "" {
+		return nil, m.errorf("expected measurement")
+	}
+
+	tags := make(map[string]string)
+	for m.peek() == ',' {
+		m.pos++
+		key := m.key(",= ", ",= ")
+		if key == "" {
+			return nil, m.errorf("expected tag key")
+		}
+		if m.peek() != '=' {
+			return nil, m.errorf("expected '=' after tag key")
+		}
+		m.pos++
+		value := m.key(",= ", ",= ")
+		if value == "" {
+			return nil, m.errorf("expected tag value")
+		}
+		tags[key] = value
+	}
+
+	if m.eol() {
+		return nil, m.errorf("missing fields")
+	}
+	if m.peek() != ' ' {
+		return nil, m.errorf("expected space after measurement")
+	}
+	m.skip(" ")
+
+	fields := make(map[string]interface{})
+	for {
+		key := m.key(",= ", ",= ")
+		if key == "" {
+			return nil, m.errorf("expected field key")
+		}
+		if m.peek() != '=' {
+			return nil, m.errorf("expected '=' after field key")
+		}
+		m.pos++
+		value, err := m.fieldValue()
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = value
+
+		if m.peek() != ',' {
+			break
+		}
+		m.pos++
+	}
+
+	tm := now
+	m.skip(" ")
+	if !m.eol() {
+		start := m.pos
+		token := m.token()
+		ts, err := strconv.ParseInt(token, 10, 64)
+		if err != nil {
+			m.pos = start
+			return nil, m.errorf("invalid timestamp")
+		}
+		unit := int64(m.precision)
+		if ts > math.MaxInt64/unit || ts < math.MinInt64/unit {
+			m.pos = start
+			return nil, m.errorf("timestamp out of range")
+		}
+		tm = time.Unix(0, ts*unit)
+	}
+
+	m.skip(" \t\r")
+	if !m.eol() {
+		return nil, m.errorf("unexpected text after timestamp")
+	}
+
+	return metric.New(name, tags, fields, tm)
+}
+
+// fieldValue parses a field value: a quoted string, a boolean, an integer
+// with an i suffix, an unsigned integer with a u suffix or a float.
+func (m *machine) fieldValue() (interface{}, error) {
+	if m.peek() == '"' {
+		return m.str()
+	}
+
+	start := m.pos
+	token := m.token()
+	switch token {
+	case "":
+		return nil, m.errorf("expected field value")
+	case "t", "T", "true", "True", "TRUE":
+		return true, nil
+	case "f", "F", "false", "False", "FALSE":
+		return false, nil
+	}
+
+	var value interface{}
+	var err error
+	switch token[len(token)-1] {
+	case 'i':
+		value, err = strconv.ParseInt(token[:len(token)-1], 10, 64)
+	case 'u':
+		value, err = strconv.ParseUint(token[:len(token)-1], 10, 64)
+	default:
+		// ParseFloat also accepts words such as "inf" and "nan", which are
+		// not valid field values.
+		if strings.IndexAny(token[:1], "+-.0123456789") != 0 {
+			err = strconv.ErrSyntax
+		} else {
+			var f float64
+			f, err = strconv.ParseFloat(token, 64)
+			if err == nil && (math.IsInf(f, 0) || math.IsNaN(f)) {
+				err = strconv.ErrSyntax
+			}
+			value = f
+		}
+	}
+	if err != nil {
+		m.pos = start
+		return nil, m.errorf("invalid field value")
+	}
+	return value, nil
+}
+
+// str parses a quoted string, the string may span lines.
+func (m *machine) str() (string, error) {
+	m.pos++
+
+	var b strings.Builder
+	for !m.eof() {
+		c := m.buf[m.pos]
+		switch {
+		case c == '"':
+			m.pos++
+			return b.String(), nil
+		case c == '\\' && m.pos+1 < len(m.buf) &&
+			(m.buf[m.pos+1] == '"' || m.buf[m.pos+1] == '\\'):
+			b.WriteByte(m.buf[m.pos+1])
+			m.pos += 2
+		case c == '\n':
+			b.WriteByte(c)
+			m.newline()
+		default:
+			b.WriteByte(c)
+			m.pos++
+		}
+	}
+
+	err := m.errorf("unterminated string")
+	err.incomplete = true
+	return "", err
+}
+
+// key reads up to the next unescaped stop character or the end of the line.
+// A backslash before any of the escapable characters is removed.
+func (m *machine) key(stops, escapable string) string {
+	var b strings.Builder
+	for !m.eol() {
+		c := m.buf[m.pos]
+		if c == '\\' && m.pos+1 < len(m.buf) &&
+			strings.IndexByte(escapable, m.buf[m.pos+1]) >= 0 {
+			b.WriteByte(m.buf[m.pos+1])
+			m.pos += 2
+			continue
+		}
+		if strings.IndexByte(stops, c) >= 0 {
+			break
+		}
+		b.WriteByte(c)
+		m.pos++
+	}
+	return b.String()
+}
+
+// token reads up to the next comma, space or end of the line.
+func (m *machine) token() string {
+	start := m.pos
+	for !m.eol() && m.buf[m.pos] != ',' && m.buf[m.pos] != ' ' &&
+		m.buf[m.pos] != '\r' {
+		m.pos++
+	}
+	return string(m.buf[start:m.pos])
+}
+
+func (m *machine) skip(chars string) {
+	for !m.eof() && strings.IndexByte(chars, m.buf[m.pos]) >= 0 {
+		m.pos++
+	}
+}
+
+// skipLine moves to the start of the next line.
+func (m *machine) skipLine() {
+	for !m.eof() {
+		if m.buf[m.pos] == '\n' {
+			m.newline()
+			return
+		}
+		m.pos++
+	}
+}
+
+// newline moves past a newline character.
+func (m *machine) newline() {
+	m.pos++
+	m.line++
+	m.lineStart = m.pos
+}
+
+func (m *machine) peek() byte {
+	if m.eof() {
+		return 0
+	}
+	return m.buf[m.pos]
+}
+
+func (m *machine) eof() bool {
+	return m.pos >= len(m.buf)
+}
+
+func (m *machine) eol() bool {
+	return m.eof() || m.buf[m.pos] == '\n'
+}
+
+// errorf creates a ParseError at the current position.
+func (m *machine) errorf(format string, args ...interface{}) *ParseError {
+	end := m.lineStart
+	for end < len(m.buf) && m.buf[end] != '\n' && end-m.lineStart < maxErrorContext {
+		end++
+	}
+	return &ParseError{
+		Line:   m.line,
+		Column: m.pos - m.lineStart + 1,
+		Msg:    fmt.Sprintf(format, args...),
+		Text:   string(m.buf[m.lineStart:end]),
+	}
+}
@@ -0,0 +1,85 @@
+package influx
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+// StreamParser parses line protocol from an io.Reader one metric at a time,
+// only the current metric is held in memory.
+type StreamParser struct {
+	reader    *bufio.Reader
+	precision time.Duration
+	timeFunc  TimeFunc
+
+	// line is the number of lines consumed.
+	line int
+	buf  []byte
+}
+
+// NewStreamParser creates a StreamParser with nanosecond precision.
+func NewStreamParser(r io.Reader) *StreamParser {
+	return &StreamParser{
+		reader:    bufio.NewReader(r),
+		precision: time.Nanosecond,
+		timeFunc:  time.Now,
+	}
+}
+
+// SetTimePrecision sets the unit of the timestamps, one of the precisions
+// of Config.TimestampPrecision.
+func (p *StreamParser) SetTimePrecision(precision time.Duration) error {
+	precision, err := checkPrecision(precision)
+	if err != nil {
+		return err
+	}
+	p.precision = precision
+	return nil
+}
+
+// SetTimeFunc sets the function used to obtain the time of metrics without
+// a timestamp, defaults to time.Now.
+func (p *StreamParser) SetTimeFunc(f TimeFunc) {
+	p.timeFunc = f
+}
+
+// Next returns the next metric, or io.EOF once the reader is exhausted.  A
+// *ParseError is returned for an invalid metric, parsing may continue with
+// the next call.
+func (p *StreamParser) Next() (telegraf.Metric, error) {
+	for {
+		line, err := p.reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(line) == 0 && len(p.buf) == 0 {
+			return nil, io.EOF
+		}
+		p.buf = append(p.buf, line...)
+
+		m := newMachine(p.buf, p.line+1, p.precision)
+		metric, perr := m.next(p.timeFunc())
+
+		// A string may span lines, read on until it is terminated.
+		if perr, ok := perr.(*ParseError); ok && perr.incomplete && err == nil {
+			continue
+		}
+
+		p.line += bytes.Count(p.buf, []byte{'\n'})
+		p.buf = p.buf[:0]
+
+		if perr != nil {
+			return nil, perr
+		}
+		if metric != nil {
+			return metric, nil
+		}
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package influx
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+func TestStreamParser(t *testing.T) {
+	input := "# comment\ncpu value=1 1\nbad\nlog msg=\"a\nb\" 2\n\nmem used=3i 3"
+	p := NewStreamParser(strings.NewReader(input))
+	p.SetTimeFunc(func() time.Time { return testNow })
+
+	var metrics []telegraf.Metric
+	var errs []*ParseError
+	for {
+		m, err := p.Next()
+		if err == io.EOF {
+			break
+		}
+		if perr, ok := err.(*ParseError); ok {
+			errs = append(errs, perr)
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		metrics = append(metrics, m)
+	}
+
+	checkMetrics(t, metrics, []testMetric{
+		{"cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(0, 1)},
+		{"log", map[string]string{}, map[string]interface{}{"msg": "a\nb"}, time.Unix(0, 2)},
+		{"mem", map[string]string{}, map[string]interface{}{"used": int64(3)}, time.Unix(0, 3)},
+	})
+	if len(errs) != 1 || errs[0].Line != 3 || errs[0].Msg != "missing fields" {
+		t.Errorf("errors = %v, want missing fields on line 3", errs)
+	}
+}
+
+func TestStreamParserUnterminated(t *testing.T) {
+	p := NewStreamParser(strings.NewReader("log msg=\"a\nb"))
+	_, err := p.Next()
+	perr, ok := err.(*ParseError)
+	if !ok || perr.Msg != "unterminated string" {
+		t.Fatalf("Next() error = %v, want unterminated string", err)
+	}
+	if _, err := p.Next(); err != io.EOF {
+		t.Fatalf("Next() error = %v, want io.EOF", err)
+	}
+}
+
+func TestStreamParserPrecision(t *testing.T) {
+	p := NewStreamParser(strings.NewReader("cpu value=1 1600000000\n"))
+	if err := p.SetTimePrecision(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	m, err := p.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Time().Equal(testNow) {
+		t.Errorf("time = %v, want %v", m.Time(), testNow)
+	}
+}
+
+func TestStreamParserInvalidPrecision(t *testing.T) {
+	p := NewStreamParser(strings.NewReader("cpu value=1 1600000000\n"))
+	if err := p.SetTimePrecision(time.Minute); err == nil {
+		t.Fatal("SetTimePrecision() succeeded with an invalid precision")
+	}
+}
+
+func TestParserStream(t *testing.T) {
+	parser := newTestParser(t, &Config{TimestampPrecision: telegraf.Duration{Duration: time.Second}})
+	p := parser.NewStreamParser(strings.NewReader("cpu value=1 1600000000\ncpu value=2\n"))
+	for i := 0; i < 2; i++ {
+		m, err := p.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !m.Time().Equal(testNow) {
+			t.Errorf("time = %v, want %v", m.Time(), testNow)
+		}
+	}
+}
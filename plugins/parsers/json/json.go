@@ -0,0 +1,235 @@
+package json
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/filter"
+	"github.com/influxdata/tgconfig/internal/timestamp"
+	"github.com/influxdata/tgconfig/metric"
+)
+
+const (
+	Name = "json"
+)
+
+const defaultMetricName = "json"
+
+// Config contains the configuration for the JSON parser.
+type Config struct {
+	// Query is the path of the part of the document to parse, such as
+	// "data.items".  Path elements are object keys or array indexes.
+	Query string `toml:"json_query"`
+	// TagKeys are the keys, after flattening, that become tags.
+	TagKeys []string `toml:"json_tag_keys"`
+	// DisableFlatten uses only the top level keys of an object, nested
+	// objects and arrays are ignored.
+	DisableFlatten bool `toml:"json_disable_flatten"`
+	// StringFields are glob patterns of the keys with string values that
+	// become fields, other strings are ignored.
+	StringFields []string `toml:"json_string_fields"`
+	// NameKey is the key whose value is used as the measurement name,
+	// defaults to "json".
+	NameKey string `toml:"json_name_key"`
+	// TimeKey is the key containing the timestamp, when unset the time of
+	// parsing is used.
+	TimeKey string `toml:"json_time_key"`
+	// TimeFormat is "unix", "unix_ms", "unix_us", "unix_ns" or a Go time
+	// layout; required with TimeKey.
+	TimeFormat string `toml:"json_time_format"`
+	// Timezone is the location used for layouts without a zone, defaults to
+	// UTC.
+	Timezone string `toml:"json_timezone"`
+}
+
+// JSON is a parser for JSON documents.  An object becomes a metric, with
+// nested objects and arrays flattened into keys joined by "_" unless
+// flattening is disabled.  An array of objects becomes a metric per object.
+// Numbers and booleans become fields.
+type JSON struct {
+	query        []string
+	tagKeys      map[string]bool
+	flatten      bool
+	stringFields filter.Filter
+	nameKey      string
+	timeKey      string
+	timeFormat   string
+	location     *time.Location
+}
+
+func New(config *Config) (telegraf.Parser, error) {
+	stringFields, err := filter.Compile(config.StringFields)
+	if err != nil {
+		return nil, fmt.Errorf("json: %v", err)
+	}
+
+	if config.TimeKey != "" && config.TimeFormat == "" {
+		return nil, fmt.Errorf("json: json_time_format is required with json_time_key")
+	}
+
+	var location *time.Location
+	if config.Timezone != "" {
+		location, err = time.LoadLocation(config.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("json: %v", err)
+		}
+	}
+
+	p := &JSON{
+		tagKeys:      make(map[string]bool),
+		flatten:      !config.DisableFlatten,
+		stringFields: stringFields,
+		nameKey:      config.NameKey,
+		timeKey:      config.TimeKey,
+		timeFormat:   config.TimeFormat,
+		location:     location,
+	}
+	if config.Query != "" {
+		p.query = strings.Split(config.Query, ".")
+	}
+	for _, key := range config.TagKeys {
+		p.tagKeys[key] = true
+	}
+	return p, nil
+}
+
+func (p *JSON) Parse(buf []byte) ([]telegraf.Metric, error) {
+	var doc interface{}
+	if err := json.Unmarshal(buf, &doc); err != nil {
+		return nil, fmt.Errorf("json: %v", err)
+	}
+
+	doc, err := p.selectQuery(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	metrics := make([]telegraf.Metric, 0)
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		m, err := p.makeMetric(v, now)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	case []interface{}:
+		for _, item := range v {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("json: expected an array of objects")
+			}
+			m, err := p.makeMetric(obj, now)
+			if err != nil {
+				return nil, err
+			}
+			metrics = append(metrics, m)
+		}
+	case nil:
+	default:
+		return nil, fmt.Errorf("json: expected an object or an array of objects")
+	}
+	return metrics, nil
+}
+
+// selectQuery returns the part of the document at the query path.
+func (p *JSON) selectQuery(doc interface{}) (interface{}, error) {
+	for i, elem := range p.query {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			doc = v[elem]
+		case []interface{}:
+			index, err := strconv.Atoi(elem)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, fmt.Errorf("json: invalid index %q in json_query", elem)
+			}
+			doc = v[index]
+		default:
+			return nil, fmt.Errorf("json: json_query %s not found",
+				strings.Join(p.query[:i+1], "."))
+		}
+	}
+	return doc, nil
+}
+
+func (p *JSON) makeMetric(obj map[string]interface{}, now time.Time) (telegraf.Metric, error) {
+	values := make(map[string]interface{})
+	if p.flatten {
+		flatten("", obj, values)
+	} else {
+		for key, value := range obj {
+			switch value.(type) {
+			case map[string]interface{}, []interface{}, nil:
+			default:
+				values[key] = value
+			}
+		}
+	}
+
+	name := defaultMetricName
+	tm := now
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+
+	for key, value := range values {
+		switch {
+		case p.nameKey != "" && key == p.nameKey:
+			name = fmt.Sprint(value)
+		case p.timeKey != "" && key == p.timeKey:
+			t, err := timestamp.Parse(p.timeFormat, value, p.location)
+			if err != nil {
+				return nil, fmt.Errorf("json: %v", err)
+			}
+			tm = t
+		case p.tagKeys[key]:
+			tags[key] = fmt.Sprint(value)
+		default:
+			switch value.(type) {
+			case float64, bool:
+				fields[key] = value
+			case string:
+				if p.stringFields != nil && p.stringFields.Match(key) {
+					fields[key] = value
+				}
+			}
+		}
+	}
+
+	if p.timeKey != "" {
+		if _, ok := values[p.timeKey]; !ok {
+			return nil, fmt.Errorf("json: time key %s not found", p.timeKey)
+		}
+	}
+
+	return metric.New(name, tags, fields, tm)
+}
+
+// flatten adds the values of a nested document to values, with the keys of
+// nested objects and the indexes of arrays joined by "_".  Nulls are
+// skipped.
+func flatten(prefix string, doc interface{}, values map[string]interface{}) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "_" + key
+	}
+
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			flatten(join(key), value, values)
+		}
+	case []interface{}:
+		for i, value := range v {
+			flatten(join(strconv.Itoa(i)), value, values)
+		}
+	case nil:
+	default:
+		values[prefix] = v
+	}
+}
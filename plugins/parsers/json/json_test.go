@@ -0,0 +1,141 @@
+package json
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		input  string
+		want   []string
+		tags   []map[string]string
+		fields []map[string]interface{}
+	}{
+		{
+			name:   "flatten",
+			config: Config{TagKeys: []string{"host"}},
+			input:  `{"host": "a", "cpu": {"user": 1, "sys": 2}, "load": [0.5, 1], "none": null}`,
+			want:   []string{"json"},
+			tags:   []map[string]string{{"host": "a"}},
+			fields: []map[string]interface{}{{"cpu_user": 1.0, "cpu_sys": 2.0, "load_0": 0.5, "load_1": 1.0}},
+		},
+		{
+			name:   "disable flatten",
+			config: Config{DisableFlatten: true, TagKeys: []string{"host"}},
+			input:  `{"host": "a", "cpu": {"user": 1}, "load": [0.5], "up": true}`,
+			want:   []string{"json"},
+			tags:   []map[string]string{{"host": "a"}},
+			fields: []map[string]interface{}{{"up": true}},
+		},
+		{
+			name:   "query array",
+			config: Config{Query: "data.items", NameKey: "name"},
+			input:  `{"data": {"items": [{"name": "a", "v": 1}, {"name": "b", "v": 2}]}}`,
+			want:   []string{"a", "b"},
+			tags:   []map[string]string{{}, {}},
+			fields: []map[string]interface{}{{"v": 1.0}, {"v": 2.0}},
+		},
+		{
+			name:   "query index",
+			config: Config{Query: "items.1"},
+			input:  `{"items": [{"v": 1}, {"v": 2}]}`,
+			want:   []string{"json"},
+			tags:   []map[string]string{{}},
+			fields: []map[string]interface{}{{"v": 2.0}},
+		},
+		{
+			name:   "string fields",
+			config: Config{StringFields: []string{"state*"}},
+			input:  `{"state": "up", "other": "x", "v": 1}`,
+			want:   []string{"json"},
+			tags:   []map[string]string{{}},
+			fields: []map[string]interface{}{{"state": "up", "v": 1.0}},
+		},
+		{
+			name:  "null document",
+			input: `null`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(&tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			metrics, err := p.Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(metrics) != len(tt.want) {
+				t.Fatalf("got %d metrics, want %d", len(metrics), len(tt.want))
+			}
+			for i, m := range metrics {
+				if m.Name() != tt.want[i] {
+					t.Errorf("name = %q, want %q", m.Name(), tt.want[i])
+				}
+				if !reflect.DeepEqual(m.Tags(), tt.tags[i]) {
+					t.Errorf("tags = %v, want %v", m.Tags(), tt.tags[i])
+				}
+				if !reflect.DeepEqual(m.Fields(), tt.fields[i]) {
+					t.Errorf("fields = %v, want %v", m.Fields(), tt.fields[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	p, err := New(&Config{
+		TimeKey:    "time",
+		TimeFormat: "2006-01-02 15:04:05",
+		Timezone:   "America/New_York",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics, err := p.Parse([]byte(`{"time": "2020-09-13 08:26:40", "v": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Unix(1600000000, 0)
+	if !metrics[0].Time().Equal(want) {
+		t.Errorf("time = %v, want %v", metrics[0].Time(), want)
+	}
+	if _, ok := metrics[0].Fields()["time"]; ok {
+		t.Error("time key parsed as a field")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		input  string
+	}{
+		{name: "invalid json", input: `{`},
+		{name: "scalar", input: `1`},
+		{name: "array of scalars", input: `[1]`},
+		{name: "query not found", config: Config{Query: "a.b"}, input: `{"a": 1}`},
+		{name: "query index", config: Config{Query: "2"}, input: `[{}]`},
+		{
+			name:   "missing time key",
+			config: Config{TimeKey: "time", TimeFormat: "unix"},
+			input:  `{"v": 1}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(&tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := p.Parse([]byte(tt.input)); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package logfmt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/metric"
+)
+
+const (
+	Name = "logfmt"
+)
+
+const defaultMetricName = "logfmt"
+
+// Config contains the configuration for the Logfmt parser.
+type Config struct {
+	// TagKeys are the keys that become tags.
+	TagKeys []string `toml:"logfmt_tag_keys"`
+}
+
+// Logfmt is a parser for logfmt, lines of space separated key=value pairs
+// such as:
+//
+//	level=info msg="request complete" status=200 duration=0.25
+//
+// Each line becomes a metric.  Values are converted to integers, floats or
+// booleans where possible and otherwise kept as strings.  A key without a
+// value is true.
+type Logfmt struct {
+	tagKeys map[string]bool
+}
+
+func New(config *Config) (telegraf.Parser, error) {
+	p := &Logfmt{tagKeys: make(map[string]bool)}
+	for _, key := range config.TagKeys {
+		p.tagKeys[key] = true
+	}
+	return p, nil
+}
+
+func (p *Logfmt) Parse(buf []byte) ([]telegraf.Metric, error) {
+	now := time.Now()
+	metrics := make([]telegraf.Metric, 0)
+
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		pairs, err := parseLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("logfmt: line %d: %v", lineno, err)
+		}
+
+		tags := make(map[string]string)
+		fields := make(map[string]interface{})
+		for _, pair := range pairs {
+			if p.tagKeys[pair.key] {
+				tags[pair.key] = pair.value
+				continue
+			}
+			fields[pair.key] = convert(pair)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		m, err := metric.New(defaultMetricName, tags, fields, now)
+		if err != nil {
+			return nil, fmt.Errorf("logfmt: line %d: %v", lineno, err)
+		}
+		metrics = append(metrics, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("logfmt: %v", err)
+	}
+	return metrics, nil
+}
+
+type pair struct {
+	key    string
+	value  string
+	quoted bool
+	bare   bool
+}
+
+// parseLine splits a line into its key/value pairs.  Quoted values may
+// contain spaces and backslash escaped quotes.
+func parseLine(line string) ([]pair, error) {
+	var pairs []pair
+	i := 0
+	for {
+		for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+			i++
+		}
+		if i >= len(line) {
+			return pairs, nil
+		}
+
+		start := i
+		for i < len(line) && line[i] != '=' && line[i] != ' ' && line[i] != '\t' {
+			i++
+		}
+		key := line[start:i]
+		if key == "" {
+			return nil, fmt.Errorf("expected key at column %d", start+1)
+		}
+		if i >= len(line) || line[i] != '=' {
+			pairs = append(pairs, pair{key: key, bare: true})
+			continue
+		}
+		i++
+
+		if i < len(line) && line[i] == '"' {
+			var b strings.Builder
+			i++
+			for i < len(line) && line[i] != '"' {
+				if line[i] == '\\' && i+1 < len(line) {
+					i++
+				}
+				b.WriteByte(line[i])
+				i++
+			}
+			if i >= len(line) {
+				return nil, fmt.Errorf("unterminated quoted value for key %s", key)
+			}
+			i++
+			pairs = append(pairs, pair{key: key, value: b.String(), quoted: true})
+			continue
+		}
+
+		start = i
+		for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+			i++
+		}
+		pairs = append(pairs, pair{key: key, value: line[start:i]})
+	}
+}
+
+// convert returns the field value of a pair, quoted values are always
+// strings.
+func convert(p pair) interface{} {
+	if p.bare {
+		return true
+	}
+	if p.quoted {
+		return p.value
+	}
+	if v, err := strconv.ParseInt(p.value, 10, 64); err == nil {
+		return v
+	}
+	// ParseFloat also accepts words such as "inf" and "nan".
+	if strings.IndexAny(p.value, "+-.0123456789") == 0 {
+		if v, err := strconv.ParseFloat(p.value, 64); err == nil {
+			return v
+		}
+	}
+	if v, err := strconv.ParseBool(p.value); err == nil {
+		return v
+	}
+	return p.value
+}
@@ -0,0 +1,121 @@
+package logfmt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		tagKeys    []string
+		input      string
+		wantTags   []map[string]string
+		wantFields []map[string]interface{}
+	}{
+		{
+			name:     "types",
+			input:    "count=42 ratio=0.25 neg=-1.5 ok=false msg=hello\n",
+			wantTags: []map[string]string{{}},
+			wantFields: []map[string]interface{}{{
+				"count": int64(42), "ratio": 0.25, "neg": -1.5, "ok": false, "msg": "hello",
+			}},
+		},
+		{
+			name:     "words are not floats",
+			input:    "a=inf b=nan\n",
+			wantTags: []map[string]string{{}},
+			wantFields: []map[string]interface{}{{
+				"a": "inf", "b": "nan",
+			}},
+		},
+		{
+			name:     "quoted",
+			input:    `msg="request \"a\" complete" code="200"` + "\n",
+			wantTags: []map[string]string{{}},
+			wantFields: []map[string]interface{}{{
+				"msg": `request "a" complete`, "code": "200",
+			}},
+		},
+		{
+			name:       "bare key",
+			input:      "debug\tcount=1\n",
+			wantTags:   []map[string]string{{}},
+			wantFields: []map[string]interface{}{{"debug": true, "count": int64(1)}},
+		},
+		{
+			name:       "tag keys",
+			tagKeys:    []string{"level", "host"},
+			input:      "level=info host=a status=200\n",
+			wantTags:   []map[string]string{{"level": "info", "host": "a"}},
+			wantFields: []map[string]interface{}{{"status": int64(200)}},
+		},
+		{
+			name:     "lines without fields are skipped",
+			tagKeys:  []string{"level"},
+			input:    "level=info\n\nstatus=200\n",
+			wantTags: []map[string]string{{}},
+			wantFields: []map[string]interface{}{
+				{"status": int64(200)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(&Config{TagKeys: tt.tagKeys})
+			if err != nil {
+				t.Fatal(err)
+			}
+			metrics, err := p.Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(metrics) != len(tt.wantFields) {
+				t.Fatalf("got %d metrics, want %d", len(metrics), len(tt.wantFields))
+			}
+			for i, m := range metrics {
+				if m.Name() != "logfmt" {
+					t.Errorf("name = %q, want %q", m.Name(), "logfmt")
+				}
+				if !reflect.DeepEqual(m.Tags(), tt.wantTags[i]) {
+					t.Errorf("tags = %v, want %v", m.Tags(), tt.wantTags[i])
+				}
+				if !reflect.DeepEqual(m.Fields(), tt.wantFields[i]) {
+					t.Errorf("fields = %v, want %v", m.Fields(), tt.wantFields[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "missing key",
+			input:   "a=1\n =2\n",
+			wantErr: "logfmt: line 2: expected key at column 2",
+		},
+		{
+			name:    "unterminated quote",
+			input:   `msg="oops` + "\n",
+			wantErr: "unterminated quoted value for key msg",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(&Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = p.Parse([]byte(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
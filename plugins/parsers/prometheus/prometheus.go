@@ -0,0 +1,318 @@
+package prometheus
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/metric"
+)
+
+const (
+	Name = "prometheus"
+)
+
+type Config struct{}
+
+// Prometheus is a parser for the Prometheus text exposition format and
+// OpenMetrics.
+//
+// Each metric family becomes a measurement with the labels as tags.
+// Counters have a "counter" field, gauges a "gauge" field and untyped
+// metrics a "value" field.  The samples of a summary or histogram with the
+// same labels are combined into one metric with "sum" and "count" fields
+// and a field for each quantile or bucket upper bound.
+type Prometheus struct{}
+
+func New(config *Config) (telegraf.Parser, error) {
+	return &Prometheus{}, nil
+}
+
+// sample is a single line of the exposition.
+type sample struct {
+	name   string
+	labels map[string]string
+	value  float64
+	time   time.Time
+}
+
+// series accumulates the fields of a metric.
+type series struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+	tp     telegraf.ValueType
+	time   time.Time
+}
+
+func (p *Prometheus) Parse(buf []byte) ([]telegraf.Metric, error) {
+	// OpenMetrics timestamps are in seconds rather than milliseconds, an
+	// OpenMetrics exposition always ends with "# EOF".
+	openMetrics := bytes.HasSuffix(bytes.TrimSpace(buf), []byte("# EOF"))
+
+	now := time.Now()
+	types := make(map[string]string)
+	index := make(map[string]*series)
+	var order []*series
+
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 && fields[1] == "EOF" {
+				break
+			}
+			if len(fields) >= 4 && fields[1] == "TYPE" {
+				types[fields[2]] = strings.ToLower(fields[3])
+			}
+			continue
+		}
+
+		s, err := parseSample(line, now, openMetrics)
+		if err != nil {
+			return nil, fmt.Errorf("prometheus: line %d: %v", lineno, err)
+		}
+
+		family, typ, suffix := lookupFamily(s.name, types)
+		if suffix == "_created" {
+			continue
+		}
+
+		var field string
+		tags := s.labels
+		tp := telegraf.Untyped
+		switch typ {
+		case "counter":
+			field, tp = "counter", telegraf.Counter
+		case "gauge":
+			field, tp = "gauge", telegraf.Gauge
+		case "summary", "histogram", "gaugehistogram":
+			var label string
+			switch suffix {
+			case "_sum", "_gsum":
+				field = "sum"
+			case "_count", "_gcount":
+				field = "count"
+			case "_bucket":
+				label = "le"
+			default:
+				label = "quantile"
+			}
+			if label != "" {
+				field = tags[label]
+				tags = without(tags, label)
+			}
+			if field == "" {
+				return nil, fmt.Errorf("prometheus: line %d: %s sample without %s label",
+					lineno, typ, label)
+			}
+		default:
+			field = "value"
+		}
+
+		key := family + "\n" + tagKey(tags)
+		ser, ok := index[key]
+		if !ok {
+			ser = &series{
+				name:   family,
+				tags:   tags,
+				fields: make(map[string]interface{}),
+				tp:     tp,
+				time:   s.time,
+			}
+			index[key] = ser
+			order = append(order, ser)
+		}
+		ser.fields[field] = s.value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("prometheus: %v", err)
+	}
+
+	metrics := make([]telegraf.Metric, 0, len(order))
+	for _, ser := range order {
+		m, err := metric.New(ser.name, ser.tags, ser.fields, ser.time, ser.tp)
+		if err != nil {
+			return nil, fmt.Errorf("prometheus: %v", err)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// lookupFamily finds the family of a sample from the declared types,
+// returning the family name, its type and the suffix of the sample name.
+// Samples without a declared family are untyped.
+func lookupFamily(name string, types map[string]string) (string, string, string) {
+	if typ, ok := types[name]; ok {
+		return name, typ, ""
+	}
+	for _, suffix := range []string{
+		"_total", "_created", "_bucket", "_sum", "_count", "_gsum", "_gcount",
+	} {
+		if !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		family := strings.TrimSuffix(name, suffix)
+		if typ, ok := types[family]; ok {
+			return family, typ, suffix
+		}
+	}
+	return name, "untyped", ""
+}
+
+// parseSample parses a line of the form:
+//
+//	name{label="value",...} value [timestamp] [# exemplar]
+func parseSample(line string, now time.Time, openMetrics bool) (*sample, error) {
+	s := &sample{labels: make(map[string]string), time: now}
+
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return nil, fmt.Errorf("invalid sample: %q", line)
+	}
+	s.name = line[:end]
+	rest := line[end:]
+
+	if rest[0] == '{' {
+		var err error
+		rest, err = parseLabels(rest[1:], s.labels)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Drop any OpenMetrics exemplar.
+	if i := strings.Index(rest, "#"); i >= 0 {
+		rest = rest[:i]
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) < 1 || len(fields) > 2 {
+		return nil, fmt.Errorf("invalid sample: %q", line)
+	}
+
+	value, err := parseValue(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	s.value = value
+
+	if len(fields) == 2 {
+		ts, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp: %q", fields[1])
+		}
+		if openMetrics {
+			whole, frac := math.Modf(ts)
+			s.time = time.Unix(int64(whole), int64(frac*1e9))
+		} else {
+			s.time = time.Unix(0, int64(ts)*int64(time.Millisecond))
+		}
+	}
+	return s, nil
+}
+
+// parseLabels parses the labels following the opening brace, returning the
+// remainder of the line after the closing brace.
+func parseLabels(s string, labels map[string]string) (string, error) {
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if strings.HasPrefix(s, "}") {
+			return s[1:], nil
+		}
+
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return "", fmt.Errorf("invalid label")
+		}
+		name := strings.TrimSpace(s[:eq])
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		if !strings.HasPrefix(s, `"`) {
+			return "", fmt.Errorf("label %s value is not quoted", name)
+		}
+
+		var b strings.Builder
+		i := 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					b.WriteByte('\n')
+				default:
+					b.WriteByte(s[i])
+				}
+				continue
+			}
+			b.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return "", fmt.Errorf("label %s value is not terminated", name)
+		}
+		labels[name] = b.String()
+
+		s = strings.TrimLeft(s[i+1:], " \t")
+		if strings.HasPrefix(s, ",") {
+			s = s[1:]
+		}
+	}
+}
+
+func parseValue(s string) (float64, error) {
+	switch s {
+	case "+Inf", "Inf":
+		return math.Inf(1), nil
+	case "-Inf":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value: %q", s)
+	}
+	return value, nil
+}
+
+// without returns a copy of the labels without the named label.
+func without(labels map[string]string, name string) map[string]string {
+	out := make(map[string]string, len(labels))
+	for k, v := range labels {
+		if k != name {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// tagKey returns a string identifying the set of tags.
+func tagKey(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(tags[k])
+		b.WriteByte(',')
+	}
+	return b.String()
+}
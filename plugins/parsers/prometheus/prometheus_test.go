@@ -0,0 +1,186 @@
+package prometheus
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+type testMetric struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+	tp     telegraf.ValueType
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []testMetric
+	}{
+		{
+			name: "counter and gauge",
+			input: `# HELP http_requests_total Requests.
+# TYPE http_requests_total counter
+http_requests_total{method="get",code="200"} 1027
+http_requests_total{method="post",code="200"} 3
+# TYPE temperature gauge
+temperature{room="a \"b\"\\c\nd"} -1.5
+`,
+			want: []testMetric{
+				{"http_requests_total", map[string]string{"method": "get", "code": "200"},
+					map[string]interface{}{"counter": 1027.0}, telegraf.Counter},
+				{"http_requests_total", map[string]string{"method": "post", "code": "200"},
+					map[string]interface{}{"counter": 3.0}, telegraf.Counter},
+				{"temperature", map[string]string{"room": "a \"b\"\\c\nd"},
+					map[string]interface{}{"gauge": -1.5}, telegraf.Gauge},
+			},
+		},
+		{
+			name:  "untyped",
+			input: "up 1\n",
+			want: []testMetric{
+				{"up", map[string]string{}, map[string]interface{}{"value": 1.0}, telegraf.Untyped},
+			},
+		},
+		{
+			name: "summary",
+			input: `# TYPE rpc_seconds summary
+rpc_seconds{service="a",quantile="0.5"} 0.2
+rpc_seconds{service="a",quantile="0.99"} 1.5
+rpc_seconds_sum{service="a"} 100
+rpc_seconds_count{service="a"} 50
+`,
+			want: []testMetric{
+				{"rpc_seconds", map[string]string{"service": "a"}, map[string]interface{}{
+					"0.5": 0.2, "0.99": 1.5, "sum": 100.0, "count": 50.0,
+				}, telegraf.Untyped},
+			},
+		},
+		{
+			name: "histogram",
+			input: `# TYPE latency histogram
+latency_bucket{le="0.1"} 5
+latency_bucket{le="+Inf"} 8
+latency_sum 1.2
+latency_count 8
+`,
+			want: []testMetric{
+				{"latency", map[string]string{}, map[string]interface{}{
+					"0.1": 5.0, "+Inf": 8.0, "sum": 1.2, "count": 8.0,
+				}, telegraf.Untyped},
+			},
+		},
+		{
+			name: "openmetrics",
+			input: `# TYPE jobs counter
+jobs_total{queue="a"} 4 1600000000.5 # {trace_id="x"} 1
+jobs_created{queue="a"} 1500000000
+# EOF
+`,
+			want: []testMetric{
+				{"jobs", map[string]string{"queue": "a"},
+					map[string]interface{}{"counter": 4.0}, telegraf.Counter},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(&Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			metrics, err := p.Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(metrics) != len(tt.want) {
+				t.Fatalf("got %d metrics, want %d", len(metrics), len(tt.want))
+			}
+			for i, m := range metrics {
+				want := tt.want[i]
+				if m.Name() != want.name {
+					t.Errorf("name = %q, want %q", m.Name(), want.name)
+				}
+				if !reflect.DeepEqual(m.Tags(), want.tags) {
+					t.Errorf("tags = %v, want %v", m.Tags(), want.tags)
+				}
+				if !reflect.DeepEqual(m.Fields(), want.fields) {
+					t.Errorf("fields = %v, want %v", m.Fields(), want.fields)
+				}
+				if m.Type() != want.tp {
+					t.Errorf("type = %v, want %v", m.Type(), want.tp)
+				}
+			}
+		})
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  time.Time
+	}{
+		{
+			name:  "milliseconds",
+			input: "up 1 1600000000500\n",
+			want:  time.Unix(1600000000, 500000000),
+		},
+		{
+			name:  "openmetrics seconds",
+			input: "up 1 1600000000.5\n# EOF\n",
+			want:  time.Unix(1600000000, 500000000),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(&Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			metrics, err := p.Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !metrics[0].Time().Equal(tt.want) {
+				t.Errorf("time = %v, want %v", metrics[0].Time(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "no value", input: "up\n", wantErr: "invalid sample"},
+		{name: "invalid value", input: "up x\n", wantErr: "invalid value"},
+		{name: "invalid timestamp", input: "up 1 x\n", wantErr: "invalid timestamp"},
+		{name: "unquoted label", input: "up{a=b} 1\n", wantErr: "not quoted"},
+		{name: "unterminated label", input: "up{a=\"b} 1\n", wantErr: "not terminated"},
+		{
+			name:    "bucket without le",
+			input:   "# TYPE latency histogram\nlatency_bucket 1\n",
+			wantErr: "line 2: histogram sample without le label",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(&Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = p.Parse([]byte(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,10 +2,22 @@ package parsers
 
 import (
 	"github.com/influxdata/tgconfig/plugins/parsers/collectd"
+	"github.com/influxdata/tgconfig/plugins/parsers/csv"
+	"github.com/influxdata/tgconfig/plugins/parsers/graphite"
+	"github.com/influxdata/tgconfig/plugins/parsers/grok"
 	"github.com/influxdata/tgconfig/plugins/parsers/influx"
+	"github.com/influxdata/tgconfig/plugins/parsers/json"
+	"github.com/influxdata/tgconfig/plugins/parsers/logfmt"
+	"github.com/influxdata/tgconfig/plugins/parsers/prometheus"
 )
 
 var Parsers = map[string]interface{}{
-	influx.Name:   influx.New,
-	collectd.Name: collectd.New,
+	influx.Name:     influx.New,
+	collectd.Name:   collectd.New,
+	json.Name:       json.New,
+	csv.Name:        csv.New,
+	prometheus.Name: prometheus.New,
+	graphite.Name:   graphite.New,
+	logfmt.Name:     logfmt.New,
+	grok.Name:       grok.New,
 }
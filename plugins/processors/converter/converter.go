@@ -0,0 +1,274 @@
+package converter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/filter"
+)
+
+const (
+	Name = "converter"
+)
+
+// Conversion lists the keys, as glob patterns, to convert to each type.
+type Conversion struct {
+	Tag      []string `toml:"tag"`
+	String   []string `toml:"string"`
+	Integer  []string `toml:"integer"`
+	Unsigned []string `toml:"unsigned"`
+	Boolean  []string `toml:"boolean"`
+	Float    []string `toml:"float"`
+}
+
+// Config contains the configuration for Converter.  Tags are converted to
+// fields of the given type, except for Tag which is not valid for tags.
+// Fields are converted to the given type, or to a tag for Tag.
+type Config struct {
+	Tags   Conversion `toml:"tags"`
+	Fields Conversion `toml:"fields"`
+}
+
+// Converter is a processor that converts tags and fields between types.
+type Converter struct {
+	tags   *conversion
+	fields *conversion
+}
+
+type conversion struct {
+	tag      filter.Filter
+	str      filter.Filter
+	integer  filter.Filter
+	unsigned filter.Filter
+	boolean  filter.Filter
+	float    filter.Filter
+}
+
+// New creates a Converter from a Config.
+func New(config *Config) ([]telegraf.Processor, error) {
+	if len(config.Tags.Tag) > 0 {
+		return nil, fmt.Errorf("converter: tags cannot be converted to tags")
+	}
+
+	tags, err := compile(&config.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("converter: %v", err)
+	}
+	fields, err := compile(&config.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("converter: %v", err)
+	}
+	return []telegraf.Processor{&Converter{tags: tags, fields: fields}}, nil
+}
+
+func compile(c *Conversion) (*conversion, error) {
+	var err error
+	compile := func(patterns []string) filter.Filter {
+		if err != nil {
+			return nil
+		}
+		var f filter.Filter
+		f, err = filter.Compile(patterns)
+		return f
+	}
+
+	conv := &conversion{
+		tag:      compile(c.Tag),
+		str:      compile(c.String),
+		integer:  compile(c.Integer),
+		unsigned: compile(c.Unsigned),
+		boolean:  compile(c.Boolean),
+		float:    compile(c.Float),
+	}
+	if err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+func (p *Converter) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		p.convertTags(m)
+		p.convertFields(m)
+	}
+	return in
+}
+
+func (p *Converter) convertTags(m telegraf.Metric) {
+	// Copy the list since it is modified while converting.
+	tags := append([]*telegraf.Tag(nil), m.TagList()...)
+	for _, tag := range tags {
+		value, ok := p.tags.convert(tag.Key, tag.Value)
+		if !ok {
+			continue
+		}
+		m.RemoveTag(tag.Key)
+		if value != nil {
+			m.AddField(tag.Key, value)
+		}
+	}
+}
+
+func (p *Converter) convertFields(m telegraf.Metric) {
+	fields := append([]*telegraf.Field(nil), m.FieldList()...)
+	for _, field := range fields {
+		if match(p.fields.tag, field.Key) {
+			m.RemoveField(field.Key)
+			m.AddTag(field.Key, toString(field.Value))
+			continue
+		}
+
+		value, ok := p.fields.convert(field.Key, field.Value)
+		if !ok {
+			continue
+		}
+		if value == nil {
+			m.RemoveField(field.Key)
+		} else {
+			m.AddField(field.Key, value)
+		}
+	}
+}
+
+// convert converts the value if the key is selected for conversion.  It
+// returns a nil value if the key was selected but the conversion failed.
+func (c *conversion) convert(key string, value interface{}) (interface{}, bool) {
+	switch {
+	case match(c.str, key):
+		return toString(value), true
+	case match(c.integer, key):
+		v, ok := toInteger(value)
+		return orNil(v, ok), true
+	case match(c.unsigned, key):
+		v, ok := toUnsigned(value)
+		return orNil(v, ok), true
+	case match(c.boolean, key):
+		v, ok := toBool(value)
+		return orNil(v, ok), true
+	case match(c.float, key):
+		v, ok := toFloat(value)
+		return orNil(v, ok), true
+	}
+	return nil, false
+}
+
+func match(f filter.Filter, key string) bool {
+	return f != nil && f.Match(key)
+}
+
+func orNil(v interface{}, ok bool) interface{} {
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func toInteger(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return v, true
+	case uint64:
+		if v > math.MaxInt64 {
+			return math.MaxInt64, true
+		}
+		return int64(v), true
+	case float64:
+		if v < math.MinInt64 || v > math.MaxInt64 || math.IsNaN(v) {
+			return 0, false
+		}
+		return int64(math.Round(v)), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		if i, err := strconv.ParseInt(v, 0, 64); err == nil {
+			return i, true
+		}
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return toInteger(f)
+		}
+	}
+	return 0, false
+}
+
+func toUnsigned(v interface{}) (uint64, bool) {
+	switch v := v.(type) {
+	case int64:
+		if v < 0 {
+			return 0, true
+		}
+		return uint64(v), true
+	case uint64:
+		return v, true
+	case float64:
+		if v < 0 || v > math.MaxUint64 || math.IsNaN(v) {
+			return 0, false
+		}
+		return uint64(math.Round(v)), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		if u, err := strconv.ParseUint(v, 0, 64); err == nil {
+			return u, true
+		}
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return toUnsigned(f)
+		}
+	}
+	return 0, false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toBool(v interface{}) (bool, bool) {
+	switch v := v.(type) {
+	case int64:
+		return v != 0, true
+	case uint64:
+		return v != 0, true
+	case float64:
+		return v != 0, true
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(v)
+		return b, err == nil
+	}
+	return false, false
+}
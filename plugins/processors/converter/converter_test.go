@@ -0,0 +1,147 @@
+package converter
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/tgconfig/metric"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     Config
+		tags       map[string]string
+		fields     map[string]interface{}
+		wantTags   map[string]string
+		wantFields map[string]interface{}
+	}{
+		{
+			name: "tags to fields",
+			config: Config{Tags: Conversion{
+				String:   []string{"host"},
+				Integer:  []string{"port"},
+				Unsigned: []string{"id"},
+				Boolean:  []string{"up"},
+				Float:    []string{"load*"},
+			}},
+			tags: map[string]string{
+				"host": "a", "port": "0x50", "id": "7", "up": "true", "load1": "0.5", "region": "eu",
+			},
+			wantTags: map[string]string{"region": "eu"},
+			wantFields: map[string]interface{}{
+				"host": "a", "port": int64(80), "id": uint64(7), "up": true, "load1": 0.5,
+			},
+		},
+		{
+			name:       "failed tag conversion removes the tag",
+			config:     Config{Tags: Conversion{Integer: []string{"port"}}},
+			tags:       map[string]string{"port": "http"},
+			fields:     map[string]interface{}{"value": 1.0},
+			wantTags:   map[string]string{},
+			wantFields: map[string]interface{}{"value": 1.0},
+		},
+		{
+			name:       "fields to tags",
+			config:     Config{Fields: Conversion{Tag: []string{"code", "ratio"}}},
+			fields:     map[string]interface{}{"code": int64(200), "ratio": 0.25, "value": 1.0},
+			wantTags:   map[string]string{"code": "200", "ratio": "0.25"},
+			wantFields: map[string]interface{}{"value": 1.0},
+		},
+		{
+			name: "field types",
+			config: Config{Fields: Conversion{
+				String:   []string{"s"},
+				Integer:  []string{"i*"},
+				Unsigned: []string{"u*"},
+				Boolean:  []string{"b"},
+				Float:    []string{"f"},
+			}},
+			fields: map[string]interface{}{
+				"s":     1.5,
+				"i1":    2.5,
+				"i2":    uint64(math.MaxUint64),
+				"i3":    "1e3",
+				"i4":    true,
+				"u1":    int64(-5),
+				"u2":    "12",
+				"b":     int64(0),
+				"f":     "3.25",
+				"other": "x",
+			},
+			wantTags: map[string]string{},
+			wantFields: map[string]interface{}{
+				"s":     "1.5",
+				"i1":    int64(3),
+				"i2":    int64(math.MaxInt64),
+				"i3":    int64(1000),
+				"i4":    int64(1),
+				"u1":    uint64(0),
+				"u2":    uint64(12),
+				"b":     false,
+				"f":     3.25,
+				"other": "x",
+			},
+		},
+		{
+			name:       "failed field conversion removes the field",
+			config:     Config{Fields: Conversion{Float: []string{"f"}, Boolean: []string{"b"}}},
+			fields:     map[string]interface{}{"f": "fast", "b": "maybe", "value": 1.0},
+			wantTags:   map[string]string{},
+			wantFields: map[string]interface{}{"value": 1.0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processors, err := New(&tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fields := tt.fields
+			if fields == nil {
+				fields = map[string]interface{}{}
+			}
+			m, err := metric.New("test", tt.tags, fields, time.Unix(0, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := processors[0].Apply(m)
+			if !reflect.DeepEqual(out[0].Tags(), tt.wantTags) {
+				t.Errorf("tags = %v, want %v", out[0].Tags(), tt.wantTags)
+			}
+			if !reflect.DeepEqual(out[0].Fields(), tt.wantFields) {
+				t.Errorf("fields = %v, want %v", out[0].Fields(), tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{
+			name:    "tags to tags",
+			config:  Config{Tags: Conversion{Tag: []string{"host"}}},
+			wantErr: "tags cannot be converted to tags",
+		},
+		{
+			name:    "invalid pattern",
+			config:  Config{Fields: Conversion{Float: []string{"["}}},
+			wantErr: "converter: ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(&tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("New() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package processors
+
+import (
+	"github.com/influxdata/tgconfig/plugins/processors/converter"
+	"github.com/influxdata/tgconfig/plugins/processors/rename"
+)
+
+var Processors = map[string]interface{}{
+	converter.Name: converter.New,
+	rename.Name:    rename.New,
+}
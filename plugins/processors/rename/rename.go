@@ -0,0 +1,71 @@
+package rename
+
+import (
+	"fmt"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+const (
+	Name = "rename"
+)
+
+// Replace renames a single measurement, tag or field to Dest.  Exactly one
+// of Measurement, Tag or Field must be set.
+type Replace struct {
+	Measurement string `toml:"measurement"`
+	Tag         string `toml:"tag"`
+	Field       string `toml:"field"`
+	Dest        string `toml:"dest"`
+}
+
+// Config contains the configuration for Rename.
+type Config struct {
+	Replaces []Replace `toml:"replace"`
+}
+
+// Rename is a processor that renames measurements, tags and fields.
+type Rename struct {
+	Replaces []Replace
+}
+
+// New creates a Rename from a Config.
+func New(config *Config) ([]telegraf.Processor, error) {
+	for _, r := range config.Replaces {
+		set := 0
+		for _, s := range []string{r.Measurement, r.Tag, r.Field} {
+			if s != "" {
+				set++
+			}
+		}
+		if set != 1 || r.Dest == "" {
+			return nil, fmt.Errorf(
+				"rename: replace must set dest and one of measurement, tag or field")
+		}
+	}
+	return []telegraf.Processor{&Rename{Replaces: config.Replaces}}, nil
+}
+
+func (p *Rename) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		for _, r := range p.Replaces {
+			switch {
+			case r.Measurement != "":
+				if m.Name() == r.Measurement {
+					m.SetName(r.Dest)
+				}
+			case r.Tag != "":
+				if value, ok := m.GetTag(r.Tag); ok {
+					m.RemoveTag(r.Tag)
+					m.AddTag(r.Dest, value)
+				}
+			case r.Field != "":
+				if value, ok := m.GetField(r.Field); ok {
+					m.RemoveField(r.Field)
+					m.AddField(r.Dest, value)
+				}
+			}
+		}
+	}
+	return in
+}
@@ -0,0 +1,108 @@
+package rename
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/tgconfig/metric"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name       string
+		replaces   []Replace
+		wantName   string
+		wantTags   map[string]string
+		wantFields map[string]interface{}
+	}{
+		{
+			name:       "measurement",
+			replaces:   []Replace{{Measurement: "cpu", Dest: "processor"}},
+			wantName:   "processor",
+			wantTags:   map[string]string{"host": "a"},
+			wantFields: map[string]interface{}{"usage": 1.5},
+		},
+		{
+			name:       "tag",
+			replaces:   []Replace{{Tag: "host", Dest: "hostname"}},
+			wantName:   "cpu",
+			wantTags:   map[string]string{"hostname": "a"},
+			wantFields: map[string]interface{}{"usage": 1.5},
+		},
+		{
+			name:       "field",
+			replaces:   []Replace{{Field: "usage", Dest: "percent"}},
+			wantName:   "cpu",
+			wantTags:   map[string]string{"host": "a"},
+			wantFields: map[string]interface{}{"percent": 1.5},
+		},
+		{
+			name: "in order",
+			replaces: []Replace{
+				{Field: "usage", Dest: "percent"},
+				{Field: "percent", Dest: "value"},
+			},
+			wantName:   "cpu",
+			wantTags:   map[string]string{"host": "a"},
+			wantFields: map[string]interface{}{"value": 1.5},
+		},
+		{
+			name: "no match",
+			replaces: []Replace{
+				{Measurement: "mem", Dest: "memory"},
+				{Tag: "region", Dest: "zone"},
+				{Field: "idle", Dest: "free"},
+			},
+			wantName:   "cpu",
+			wantTags:   map[string]string{"host": "a"},
+			wantFields: map[string]interface{}{"usage": 1.5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processors, err := New(&Config{Replaces: tt.replaces})
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := metric.New("cpu", map[string]string{"host": "a"},
+				map[string]interface{}{"usage": 1.5}, time.Unix(0, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := processors[0].Apply(m)
+			if len(out) != 1 {
+				t.Fatalf("got %d metrics, want 1", len(out))
+			}
+			if out[0].Name() != tt.wantName {
+				t.Errorf("name = %q, want %q", out[0].Name(), tt.wantName)
+			}
+			if !reflect.DeepEqual(out[0].Tags(), tt.wantTags) {
+				t.Errorf("tags = %v, want %v", out[0].Tags(), tt.wantTags)
+			}
+			if !reflect.DeepEqual(out[0].Fields(), tt.wantFields) {
+				t.Errorf("fields = %v, want %v", out[0].Fields(), tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		replace Replace
+	}{
+		{name: "no dest", replace: Replace{Tag: "host"}},
+		{name: "no source", replace: Replace{Dest: "x"}},
+		{name: "several sources", replace: Replace{Tag: "host", Field: "usage", Dest: "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(&Config{Replaces: []Replace{tt.replace}})
+			if err == nil || !strings.Contains(err.Error(), "replace must set dest") {
+				t.Fatalf("New() error = %v, want replace must set dest", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+const (
+	Name = "csv"
+)
+
+// Config contains the configuration for the CSV serializer.
+type Config struct {
+	// Header writes a header row at the start of each batch, and before
+	// any metric whose columns differ from the row above it.  As every
+	// serialization starts with a header, it is best used with batches.
+	Header bool `toml:"csv_header"`
+	// Separator separates the columns, defaults to ",".
+	Separator string `toml:"csv_separator"`
+	// TimestampFormat is "unix", "unix_ms", "unix_us", "unix_ns" or a Go
+	// time layout; defaults to "unix".
+	TimestampFormat string `toml:"csv_timestamp_format"`
+}
+
+// CSV is a serializer for comma separated values.  Each metric is a row of
+// the timestamp, the measurement name, the tag values sorted by key and the
+// field values sorted by key.
+type CSV struct {
+	separator       rune
+	timestampFormat string
+	header          bool
+}
+
+func New(config *Config) (telegraf.Serializer, error) {
+	s := &CSV{
+		separator:       ',',
+		timestampFormat: config.TimestampFormat,
+		header:          config.Header,
+	}
+	if s.timestampFormat == "" {
+		s.timestampFormat = "unix"
+	}
+	if config.Separator != "" {
+		if utf8.RuneCountInString(config.Separator) != 1 {
+			return nil, fmt.Errorf("csv: csv_separator must be a single character")
+		}
+		s.separator, _ = utf8.DecodeRuneInString(config.Separator)
+	}
+	return s, nil
+}
+
+func (s *CSV) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+func (s *CSV) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	w.Comma = s.separator
+
+	// The header is kept joined so the columns of rows are easily compared.
+	var last string
+	for _, metric := range metrics {
+		tags := metric.TagList()
+		fields := append([]*telegraf.Field(nil), metric.FieldList()...)
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+
+		if s.header {
+			header := []string{"timestamp", "measurement"}
+			for _, tag := range tags {
+				header = append(header, tag.Key)
+			}
+			for _, field := range fields {
+				header = append(header, field.Key)
+			}
+			if joined := strings.Join(header, "\x00"); joined != last {
+				if err := w.Write(header); err != nil {
+					return nil, fmt.Errorf("csv: %v", err)
+				}
+				last = joined
+			}
+		}
+
+		record := []string{s.formatTime(metric), metric.Name()}
+		for _, tag := range tags {
+			record = append(record, tag.Value)
+		}
+		for _, field := range fields {
+			record = append(record, formatValue(field.Value))
+		}
+		if err := w.Write(record); err != nil {
+			return nil, fmt.Errorf("csv: %v", err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("csv: %v", err)
+	}
+	return b.Bytes(), nil
+}
+
+func (s *CSV) formatTime(metric telegraf.Metric) string {
+	tm := metric.Time()
+	switch s.timestampFormat {
+	case "unix":
+		return strconv.FormatInt(tm.Unix(), 10)
+	case "unix_ms":
+		return strconv.FormatInt(tm.UnixNano()/1e6, 10)
+	case "unix_us":
+		return strconv.FormatInt(tm.UnixNano()/1e3, 10)
+	case "unix_ns":
+		return strconv.FormatInt(tm.UnixNano(), 10)
+	}
+	return tm.UTC().Format(s.timestampFormat)
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,119 @@
+package csv
+
+import (
+	"testing"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/metric"
+)
+
+func mustMetric(t *testing.T, name string, tags map[string]string, fields map[string]interface{}) telegraf.Metric {
+	t.Helper()
+	m, err := metric.New(name, tags, fields, time.Unix(1600000000, 500000000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestSerializeBatch(t *testing.T) {
+	cpu := func(t *testing.T) telegraf.Metric {
+		return mustMetric(t, "cpu", map[string]string{"host": "a"},
+			map[string]interface{}{"user": 1.5, "idle": int64(90)})
+	}
+	mem := func(t *testing.T) telegraf.Metric {
+		return mustMetric(t, "mem", nil, map[string]interface{}{"used": uint64(10), "ok": true})
+	}
+
+	tests := []struct {
+		name    string
+		config  Config
+		metrics []func(t *testing.T) telegraf.Metric
+		want    string
+	}{
+		{
+			name:    "no header",
+			metrics: []func(t *testing.T) telegraf.Metric{cpu, mem},
+			want:    "1600000000,cpu,a,90,1.5\n1600000000,mem,true,10\n",
+		},
+		{
+			name:    "header",
+			config:  Config{Header: true},
+			metrics: []func(t *testing.T) telegraf.Metric{cpu, cpu},
+			want:    "timestamp,measurement,host,idle,user\n1600000000,cpu,a,90,1.5\n1600000000,cpu,a,90,1.5\n",
+		},
+		{
+			name:    "header on column change",
+			config:  Config{Header: true},
+			metrics: []func(t *testing.T) telegraf.Metric{cpu, mem, cpu},
+			want: "timestamp,measurement,host,idle,user\n1600000000,cpu,a,90,1.5\n" +
+				"timestamp,measurement,ok,used\n1600000000,mem,true,10\n" +
+				"timestamp,measurement,host,idle,user\n1600000000,cpu,a,90,1.5\n",
+		},
+		{
+			name:    "separator",
+			config:  Config{Separator: ";"},
+			metrics: []func(t *testing.T) telegraf.Metric{mem},
+			want:    "1600000000;mem;true;10\n",
+		},
+		{
+			name:    "unix_ms",
+			config:  Config{TimestampFormat: "unix_ms"},
+			metrics: []func(t *testing.T) telegraf.Metric{mem},
+			want:    "1600000000500,mem,true,10\n",
+		},
+		{
+			name:    "layout",
+			config:  Config{TimestampFormat: time.RFC3339},
+			metrics: []func(t *testing.T) telegraf.Metric{mem},
+			want:    "2020-09-13T12:26:40Z,mem,true,10\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(&tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var metrics []telegraf.Metric
+			for _, m := range tt.metrics {
+				metrics = append(metrics, m(t))
+			}
+			got, err := s.SerializeBatch(metrics)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("SerializeBatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSerializeHeader(t *testing.T) {
+	s, err := New(&Config{Header: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each serialization starts with the header, so that output split
+	// across files always has one.
+	m := mustMetric(t, "mem", nil, map[string]interface{}{"used": int64(10)})
+	want := "timestamp,measurement,used\n1600000000,mem,10\n"
+	for i := 0; i < 2; i++ {
+		got, err := s.Serialize(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("Serialize() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestNewInvalidSeparator(t *testing.T) {
+	if _, err := New(&Config{Separator: ";;"}); err == nil {
+		t.Fatal("expected error for multi-character separator")
+	}
+}
@@ -0,0 +1,152 @@
+package graphite
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+const (
+	Name = "graphite"
+)
+
+const defaultTemplate = "host.tags.measurement.field"
+
+// invalid matches the characters replaced in each path segment.
+var invalid = regexp.MustCompile(`[^a-zA-Z0-9\-_:#@%]`)
+
+// Config contains the configuration for the Graphite serializer.
+type Config struct {
+	// Prefix is added to the start of every path.
+	Prefix string `toml:"graphite_prefix"`
+	// Template orders the parts of the path: "host" is the host tag,
+	// "tags" the values of the remaining tags sorted by key,
+	// "measurement" and "field" the measurement and field names, and any
+	// other word the value of that tag.  Defaults to
+	// "host.tags.measurement.field".
+	Template string `toml:"graphite_template"`
+}
+
+// Graphite is a serializer for the Graphite plaintext protocol.  Each
+// numeric or boolean field is written as a line of "path value timestamp",
+// a field named "value" is left out of the path.
+type Graphite struct {
+	prefix   string
+	template []string
+}
+
+func New(config *Config) (telegraf.Serializer, error) {
+	template := config.Template
+	if template == "" {
+		template = defaultTemplate
+	}
+	return &Graphite{
+		prefix:   config.Prefix,
+		template: strings.Split(template, "."),
+	}, nil
+}
+
+func (s *Graphite) Serialize(metric telegraf.Metric) ([]byte, error) {
+	var b bytes.Buffer
+	s.write(&b, metric)
+	return b.Bytes(), nil
+}
+
+func (s *Graphite) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var b bytes.Buffer
+	for _, metric := range metrics {
+		s.write(&b, metric)
+	}
+	return b.Bytes(), nil
+}
+
+func (s *Graphite) write(b *bytes.Buffer, metric telegraf.Metric) {
+	timestamp := strconv.FormatInt(metric.Time().Unix(), 10)
+	for _, field := range metric.FieldList() {
+		value, ok := formatValue(field.Value)
+		if !ok {
+			continue
+		}
+		b.WriteString(s.path(metric, field.Key))
+		b.WriteByte(' ')
+		b.WriteString(value)
+		b.WriteByte(' ')
+		b.WriteString(timestamp)
+		b.WriteByte('\n')
+	}
+}
+
+// path builds the path of a field from the template.
+func (s *Graphite) path(metric telegraf.Metric, field string) string {
+	var segments []string
+	if s.prefix != "" {
+		segments = append(segments, s.prefix)
+	}
+
+	used := make(map[string]bool)
+	for _, part := range s.template {
+		switch part {
+		case "measurement", "field", "tags":
+		default:
+			used[part] = true
+		}
+	}
+
+	for _, part := range s.template {
+		switch part {
+		case "measurement":
+			segments = append(segments, sanitize(metric.Name()))
+		case "field":
+			if field != "value" {
+				segments = append(segments, sanitize(field))
+			}
+		case "tags":
+			tags := metric.TagList()
+			keys := make([]string, 0, len(tags))
+			for _, tag := range tags {
+				if !used[tag.Key] {
+					keys = append(keys, tag.Key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				value, _ := metric.GetTag(key)
+				segments = append(segments, sanitize(value))
+			}
+		default:
+			if value, ok := metric.GetTag(part); ok {
+				segments = append(segments, sanitize(value))
+			}
+		}
+	}
+	return strings.Join(segments, ".")
+}
+
+func sanitize(s string) string {
+	return invalid.ReplaceAllString(s, "_")
+}
+
+func formatValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		if v {
+			return "1", true
+		}
+		return "0", true
+	}
+	return "", false
+}
@@ -0,0 +1,68 @@
+package graphite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/tgconfig/metric"
+)
+
+func TestSerialize(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		tags   map[string]string
+		fields map[string]interface{}
+		want   string
+	}{
+		{
+			name:   "default template",
+			tags:   map[string]string{"host": "web.01", "region": "us", "dc": "a"},
+			fields: map[string]interface{}{"usage": 1.5},
+			want:   "web_01.a.us.cpu.usage 1.5 1600000000\n",
+		},
+		{
+			name:   "value field",
+			tags:   map[string]string{"host": "h"},
+			fields: map[string]interface{}{"value": int64(3)},
+			want:   "h.cpu 3 1600000000\n",
+		},
+		{
+			name:   "prefix and template",
+			config: Config{Prefix: "telegraf", Template: "region.measurement.host.field"},
+			tags:   map[string]string{"host": "h", "region": "eu"},
+			fields: map[string]interface{}{"usage": uint64(2)},
+			want:   "telegraf.eu.cpu.h.usage 2 1600000000\n",
+		},
+		{
+			name:   "missing tag",
+			config: Config{Template: "region.measurement.field"},
+			fields: map[string]interface{}{"usage": int64(1)},
+			want:   "cpu.usage 1 1600000000\n",
+		},
+		{
+			name:   "bool and string fields",
+			fields: map[string]interface{}{"ok": true, "state": "up"},
+			want:   "cpu.ok 1 1600000000\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(&tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := metric.New("cpu", tt.tags, tt.fields, time.Unix(1600000000, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.Serialize(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Serialize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package influx
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+const (
+	Name = "influx"
+)
+
+// Config contains the configuration for the Influx serializer.
+type Config struct {
+	// TimestampPrecision is the unit of the timestamps, one of "1ns",
+	// "1us", "1ms" or "1s"; defaults to "1ns".
+	TimestampPrecision telegraf.Duration `toml:"influx_timestamp_precision"`
+}
+
+// Influx is a serializer for the InfluxDB line protocol, it is the inverse
+// of the influx parser.
+type Influx struct {
+	precision time.Duration
+}
+
+var (
+	measurementEscaper = strings.NewReplacer(`,`, `\,`, ` `, `\ `)
+	keyEscaper         = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `)
+	stringEscaper      = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+)
+
+func New(config *Config) (telegraf.Serializer, error) {
+	precision := config.TimestampPrecision.Duration
+	switch precision {
+	case 0:
+		precision = time.Nanosecond
+	case time.Nanosecond, time.Microsecond, time.Millisecond, time.Second:
+	default:
+		return nil, fmt.Errorf("influx: invalid timestamp precision: %s", precision)
+	}
+	return &Influx{precision: precision}, nil
+}
+
+// Serialize returns the metric as a line, fields that cannot be represented
+// such as NaN are omitted and a metric without fields is empty.
+func (s *Influx) Serialize(metric telegraf.Metric) ([]byte, error) {
+	var b bytes.Buffer
+	s.write(&b, metric)
+	return b.Bytes(), nil
+}
+
+func (s *Influx) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var b bytes.Buffer
+	for _, metric := range metrics {
+		s.write(&b, metric)
+	}
+	return b.Bytes(), nil
+}
+
+func (s *Influx) write(b *bytes.Buffer, metric telegraf.Metric) {
+	var fields []string
+	for _, field := range metric.FieldList() {
+		value, ok := formatValue(field.Value)
+		if !ok {
+			continue
+		}
+		fields = append(fields, keyEscaper.Replace(field.Key)+"="+value)
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	b.WriteString(measurementEscaper.Replace(metric.Name()))
+	for _, tag := range metric.TagList() {
+		if tag.Key == "" || tag.Value == "" {
+			continue
+		}
+		b.WriteByte(',')
+		b.WriteString(keyEscaper.Replace(tag.Key))
+		b.WriteByte('=')
+		b.WriteString(keyEscaper.Replace(tag.Value))
+	}
+	b.WriteByte(' ')
+	b.WriteString(strings.Join(fields, ","))
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatInt(metric.Time().UnixNano()/int64(s.precision), 10))
+	b.WriteByte('\n')
+}
+
+func formatValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10) + "i", true
+	case uint64:
+		return strconv.FormatUint(v, 10) + "u", true
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case string:
+		return `"` + stringEscaper.Replace(v) + `"`, true
+	}
+	return "", false
+}
@@ -0,0 +1,113 @@
+package influx
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/metric"
+)
+
+func TestSerialize(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      Config
+		measurement string
+		tags        map[string]string
+		fields      map[string]interface{}
+		want        string
+	}{
+		{
+			name:        "field types",
+			measurement: "cpu",
+			tags:        map[string]string{"host": "a"},
+			fields: map[string]interface{}{
+				"f": 1.5, "i": int64(-2), "u": uint64(3), "b": true, "s": `say "hi" \o/`,
+			},
+			want: `cpu,host=a b=true,f=1.5,i=-2i,s="say \"hi\" \\o/",u=3u 1600000000500000000` + "\n",
+		},
+		{
+			name:        "escaping",
+			measurement: "my cpu,x",
+			tags:        map[string]string{"a b": "c,d=e"},
+			fields:      map[string]interface{}{"f=1": 1.0},
+			want:        `my\ cpu\,x,a\ b=c\,d\=e f\=1=1 1600000000500000000` + "\n",
+		},
+		{
+			name:        "empty tag values are omitted",
+			measurement: "cpu",
+			tags:        map[string]string{"host": ""},
+			fields:      map[string]interface{}{"f": 1.0},
+			want:        "cpu f=1 1600000000500000000\n",
+		},
+		{
+			name:        "unrepresentable fields are omitted",
+			measurement: "cpu",
+			fields:      map[string]interface{}{"nan": math.NaN(), "inf": math.Inf(1), "f": 2.0},
+			want:        "cpu f=2 1600000000500000000\n",
+		},
+		{
+			name:        "no fields",
+			measurement: "cpu",
+			fields:      map[string]interface{}{"nan": math.NaN()},
+			want:        "",
+		},
+		{
+			name:        "precision",
+			config:      Config{TimestampPrecision: telegraf.Duration{Duration: time.Second}},
+			measurement: "cpu",
+			fields:      map[string]interface{}{"f": 1.0},
+			want:        "cpu f=1 1600000000\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(&tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := metric.New(tt.measurement, tt.tags, tt.fields, time.Unix(1600000000, 500000000))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.Serialize(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Serialize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSerializeBatch(t *testing.T) {
+	s, err := New(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var metrics []telegraf.Metric
+	for _, name := range []string{"a", "b"} {
+		m, err := metric.New(name, nil, map[string]interface{}{"f": int64(1)}, time.Unix(0, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		metrics = append(metrics, m)
+	}
+	got, err := s.SerializeBatch(metrics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a f=1i 1\nb f=1i 1\n"; string(got) != want {
+		t.Errorf("SerializeBatch() = %q, want %q", got, want)
+	}
+}
+
+func TestNewInvalidPrecision(t *testing.T) {
+	_, err := New(&Config{TimestampPrecision: telegraf.Duration{Duration: time.Minute}})
+	if err == nil || !strings.Contains(err.Error(), "invalid timestamp precision") {
+		t.Fatalf("New() error = %v, want invalid timestamp precision", err)
+	}
+}
@@ -0,0 +1,83 @@
+package json
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+const (
+	Name = "json"
+)
+
+// Config contains the configuration for the JSON serializer.
+type Config struct {
+	// TimestampUnits is the unit of the timestamps; defaults to 1s.
+	TimestampUnits telegraf.Duration `toml:"json_timestamp_units"`
+}
+
+// JSON is a serializer for JSON, each metric is an object such as:
+//
+//	{"name":"cpu","tags":{"host":"a"},"fields":{"usage":1.5},"timestamp":1600000000}
+//
+// A batch is an object with a "metrics" array.
+type JSON struct {
+	units time.Duration
+}
+
+type object struct {
+	Name      string                 `json:"name"`
+	Tags      map[string]string      `json:"tags"`
+	Fields    map[string]interface{} `json:"fields"`
+	Timestamp int64                  `json:"timestamp"`
+}
+
+func New(config *Config) (telegraf.Serializer, error) {
+	units := config.TimestampUnits.Duration
+	if units <= 0 {
+		units = time.Second
+	}
+	return &JSON{units: units}, nil
+}
+
+func (s *JSON) Serialize(metric telegraf.Metric) ([]byte, error) {
+	buf, err := json.Marshal(s.object(metric))
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, '\n'), nil
+}
+
+func (s *JSON) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	objects := make([]*object, 0, len(metrics))
+	for _, metric := range metrics {
+		objects = append(objects, s.object(metric))
+	}
+
+	buf, err := json.Marshal(map[string]interface{}{"metrics": objects})
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, '\n'), nil
+}
+
+// object converts a metric, omitting NaN and infinite fields which JSON
+// cannot represent.
+func (s *JSON) object(metric telegraf.Metric) *object {
+	fields := make(map[string]interface{}, len(metric.FieldList()))
+	for _, field := range metric.FieldList() {
+		if v, ok := field.Value.(float64); ok && (math.IsNaN(v) || math.IsInf(v, 0)) {
+			continue
+		}
+		fields[field.Key] = field.Value
+	}
+
+	return &object{
+		Name:      metric.Name(),
+		Tags:      metric.Tags(),
+		Fields:    fields,
+		Timestamp: metric.Time().UnixNano() / int64(s.units),
+	}
+}
@@ -0,0 +1,80 @@
+package json
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/metric"
+)
+
+func TestSerialize(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		tags   map[string]string
+		fields map[string]interface{}
+		want   string
+	}{
+		{
+			name:   "default units",
+			tags:   map[string]string{"host": "a"},
+			fields: map[string]interface{}{"usage": 1.5, "count": int64(2), "ok": true, "state": "up"},
+			want:   `{"name":"cpu","tags":{"host":"a"},"fields":{"count":2,"ok":true,"state":"up","usage":1.5},"timestamp":1600000000}` + "\n",
+		},
+		{
+			name:   "milliseconds",
+			config: Config{TimestampUnits: telegraf.Duration{Duration: time.Millisecond}},
+			fields: map[string]interface{}{"usage": 1.5},
+			want:   `{"name":"cpu","tags":{},"fields":{"usage":1.5},"timestamp":1600000000500}` + "\n",
+		},
+		{
+			name:   "unrepresentable fields are omitted",
+			fields: map[string]interface{}{"nan": math.NaN(), "inf": math.Inf(-1), "usage": 1.0},
+			want:   `{"name":"cpu","tags":{},"fields":{"usage":1},"timestamp":1600000000}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(&tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := metric.New("cpu", tt.tags, tt.fields, time.Unix(1600000000, 500000000))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.Serialize(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Serialize() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSerializeBatch(t *testing.T) {
+	s, err := New(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var metrics []telegraf.Metric
+	for _, name := range []string{"a", "b"} {
+		m, err := metric.New(name, nil, map[string]interface{}{"f": int64(1)}, time.Unix(1, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		metrics = append(metrics, m)
+	}
+	got, err := s.SerializeBatch(metrics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"metrics":[{"name":"a","tags":{},"fields":{"f":1},"timestamp":1},{"name":"b","tags":{},"fields":{"f":1},"timestamp":1}]}` + "\n"
+	if string(got) != want {
+		t.Errorf("SerializeBatch() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,162 @@
+package prometheus
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	telegraf "github.com/influxdata/tgconfig"
+)
+
+const (
+	Name = "prometheus"
+)
+
+var (
+	invalidName  = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+	invalidLabel = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// Config contains the configuration for the Prometheus serializer.
+type Config struct {
+	// ExportTimestamp adds the timestamp, in milliseconds, to each sample.
+	ExportTimestamp bool `toml:"prometheus_export_timestamp"`
+}
+
+// Prometheus is a serializer for the Prometheus text exposition format.
+// Each numeric or boolean field becomes a sample named after the
+// measurement and field, with the tags as labels.  The fields created by
+// the prometheus parser for counters, gauges and untyped metrics, named
+// "counter", "gauge" and "value", are named after the measurement alone.
+type Prometheus struct {
+	exportTimestamp bool
+}
+
+// family is the samples with the same name.
+type family struct {
+	typ     string
+	samples []string
+}
+
+func New(config *Config) (telegraf.Serializer, error) {
+	return &Prometheus{exportTimestamp: config.ExportTimestamp}, nil
+}
+
+func (s *Prometheus) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+// SerializeBatch groups the samples of the batch into families, each with a
+// single TYPE line.
+func (s *Prometheus) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	families := make(map[string]*family)
+	for _, metric := range metrics {
+		for _, field := range metric.FieldList() {
+			value, ok := formatValue(field.Value)
+			if !ok {
+				continue
+			}
+
+			name := metric.Name()
+			switch field.Key {
+			case "counter", "gauge", "value":
+			default:
+				name += "_" + field.Key
+			}
+			name = invalidName.ReplaceAllString(name, "_")
+
+			f, ok := families[name]
+			if !ok {
+				f = &family{typ: typeOf(metric.Type())}
+				families[name] = f
+			}
+			f.samples = append(f.samples, s.sample(name, metric, value))
+		}
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	for _, name := range names {
+		f := families[name]
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, f.typ)
+		for _, sample := range f.samples {
+			b.WriteString(sample)
+		}
+	}
+	return b.Bytes(), nil
+}
+
+func (s *Prometheus) sample(name string, metric telegraf.Metric, value string) string {
+	var b strings.Builder
+	b.WriteString(name)
+
+	tags := metric.TagList()
+	if len(tags) > 0 {
+		b.WriteByte('{')
+		for i, tag := range tags {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(invalidLabel.ReplaceAllString(tag.Key, "_"))
+			b.WriteString(`="`)
+			b.WriteString(labelEscaper.Replace(tag.Value))
+			b.WriteByte('"')
+		}
+		b.WriteByte('}')
+	}
+
+	b.WriteByte(' ')
+	b.WriteString(value)
+	if s.exportTimestamp {
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatInt(metric.Time().UnixNano()/1e6, 10))
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+func typeOf(tp telegraf.ValueType) string {
+	switch tp {
+	case telegraf.Counter:
+		return "counter"
+	case telegraf.Gauge:
+		return "gauge"
+	default:
+		return "untyped"
+	}
+}
+
+func formatValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "NaN", true
+		case math.IsInf(v, 1):
+			return "+Inf", true
+		case math.IsInf(v, -1):
+			return "-Inf", true
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	case bool:
+		if v {
+			return "1", true
+		}
+		return "0", true
+	}
+	return "", false
+}
@@ -0,0 +1,97 @@
+package prometheus
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	telegraf "github.com/influxdata/tgconfig"
+	"github.com/influxdata/tgconfig/metric"
+)
+
+type testMetric struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+	tp     telegraf.ValueType
+}
+
+func TestSerializeBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		metrics []testMetric
+		want    string
+	}{
+		{
+			name: "parser fields",
+			metrics: []testMetric{
+				{"requests", map[string]string{"code": "200"}, map[string]interface{}{"counter": 5.0}, telegraf.Counter},
+				{"requests", map[string]string{"code": "500"}, map[string]interface{}{"counter": 1.0}, telegraf.Counter},
+				{"temperature", nil, map[string]interface{}{"gauge": -1.5}, telegraf.Gauge},
+				{"up", nil, map[string]interface{}{"value": int64(1)}, telegraf.Untyped},
+			},
+			want: `# TYPE requests counter
+requests{code="200"} 5
+requests{code="500"} 1
+# TYPE temperature gauge
+temperature -1.5
+# TYPE up untyped
+up 1
+`,
+		},
+		{
+			name: "field names and values",
+			metrics: []testMetric{
+				{"cpu.0", map[string]string{"host-name": "a\"b\nc"}, map[string]interface{}{
+					"busy": true, "idle": false, "nan": math.NaN(), "inf": math.Inf(1),
+					"ninf": math.Inf(-1), "count": uint64(3), "state": "up",
+				}, telegraf.Untyped},
+			},
+			want: `# TYPE cpu_0_busy untyped
+cpu_0_busy{host_name="a\"b\nc"} 1
+# TYPE cpu_0_count untyped
+cpu_0_count{host_name="a\"b\nc"} 3
+# TYPE cpu_0_idle untyped
+cpu_0_idle{host_name="a\"b\nc"} 0
+# TYPE cpu_0_inf untyped
+cpu_0_inf{host_name="a\"b\nc"} +Inf
+# TYPE cpu_0_nan untyped
+cpu_0_nan{host_name="a\"b\nc"} NaN
+# TYPE cpu_0_ninf untyped
+cpu_0_ninf{host_name="a\"b\nc"} -Inf
+`,
+		},
+		{
+			name:   "export timestamp",
+			config: Config{ExportTimestamp: true},
+			metrics: []testMetric{
+				{"up", nil, map[string]interface{}{"value": 1.0}, telegraf.Gauge},
+			},
+			want: "# TYPE up gauge\nup 1 1600000000500\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(&tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var metrics []telegraf.Metric
+			for _, tm := range tt.metrics {
+				m, err := metric.New(tm.name, tm.tags, tm.fields, time.Unix(1600000000, 500000000), tm.tp)
+				if err != nil {
+					t.Fatal(err)
+				}
+				metrics = append(metrics, m)
+			}
+			got, err := s.SerializeBatch(metrics)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("SerializeBatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package serializers
+
+import (
+	"github.com/influxdata/tgconfig/plugins/serializers/csv"
+	"github.com/influxdata/tgconfig/plugins/serializers/graphite"
+	"github.com/influxdata/tgconfig/plugins/serializers/influx"
+	"github.com/influxdata/tgconfig/plugins/serializers/json"
+	"github.com/influxdata/tgconfig/plugins/serializers/prometheus"
+)
+
+var Serializers = map[string]interface{}{
+	influx.Name:     influx.New,
+	json.Name:       json.New,
+	graphite.Name:   graphite.New,
+	prometheus.Name: prometheus.New,
+	csv.Name:        csv.New,
+}
@@ -0,0 +1,9 @@
+package telegraf
+
+// Processor is a processor plugin, it transforms metrics as they pass from
+// the Inputs to the Outputs.
+type Processor interface {
+	// Apply transforms a batch of metrics, returning the resulting metrics.
+	// Metrics may be modified in place, dropped or added.
+	Apply(in ...Metric) []Metric
+}
@@ -0,0 +1,11 @@
+package telegraf
+
+// Existing: plugins/serializers/registry.Serializer
+type Serializer interface {
+	// Serialize converts a single metric, the result may be empty if the
+	// metric cannot be represented in the format.
+	Serialize(metric Metric) ([]byte, error)
+
+	// SerializeBatch converts a batch of metrics.
+	SerializeBatch(metrics []Metric) ([]byte, error)
+}